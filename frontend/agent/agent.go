@@ -8,7 +8,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
+
+	"github.com/run-llama/study-llama/frontend/files"
 )
 
 type FilesRequestBody struct {
@@ -23,9 +27,16 @@ type InputFileEvent struct {
 	FileName string `json:"file_name"`
 }
 
+type QuestionAndAnswer struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
 type FilesResultValue struct {
-	Success bool    `json:"success"`
-	Error   *string `json:"error"`
+	Success bool                `json:"success"`
+	Error   *string             `json:"error"`
+	Summary *string             `json:"summary"`
+	Faqs    []QuestionAndAnswer `json:"faqs"`
 }
 type FilesResponseResult struct {
 	Value         FilesResultValue `json:"value"`
@@ -50,6 +61,15 @@ func (b *FilesResponseBody) GetErrorString() *string {
 	return b.Result.Value.Error
 }
 
+// GetStudyNotes returns the summary and FAQs extracted from the file, if the
+// workflow reported them
+func (b *FilesResponseBody) GetStudyNotes() (*string, []QuestionAndAnswer) {
+	if b.Result != nil {
+		return b.Result.Value.Summary, b.Result.Value.Faqs
+	}
+	return nil, nil
+}
+
 type SearchRequestBody struct {
 	StartEvent SearchInputEvent `json:"start_event"`
 	Context    map[string]any   `json:"context"`
@@ -57,11 +77,24 @@ type SearchRequestBody struct {
 }
 
 type SearchInputEvent struct {
-	SearchType  string  `json:"search_type"`
-	SearchInput string  `json:"search_input"`
-	Username    string  `json:"username"`
-	FileName    *string `json:"file_name"`
-	Category    *string `json:"category"`
+	SearchType  string   `json:"search_type"`
+	SearchInput string   `json:"search_input"`
+	Username    string   `json:"username"`
+	FileName    *string  `json:"file_name"`
+	Category    *string  `json:"category"`
+	FileNames   []string `json:"file_names"`
+	Categories  []string `json:"categories"`
+	Tags        []string `json:"tags"`
+	CourseID    *int32   `json:"course_id"`
+	// ExcludedFileNames and ExcludedCategories leave out the results coming
+	// from the given notes and categories
+	ExcludedFileNames  []string `json:"excluded_file_names"`
+	ExcludedCategories []string `json:"excluded_categories"`
+	// TopK, MinSimilarity and Offset page through the results, the backend
+	// defaults being used when they are zero
+	TopK          int     `json:"top_k,omitempty"`
+	MinSimilarity float64 `json:"min_similarity,omitempty"`
+	Offset        int     `json:"offset,omitempty"`
 }
 
 type SearchResult struct {
@@ -70,10 +103,20 @@ type SearchResult struct {
 	Similarity float64 `json:"similarity"`
 	FileName   string  `json:"file_name"`
 	Category   string  `json:"category"`
+	// Retriever is the search the result comes from, left empty by the search backend
+	Retriever string `json:"retriever,omitempty"`
+	// Score and Contributions rank the results fused from several searches
+	Score         float64        `json:"score,omitempty"`
+	Contributions []Contribution `json:"contributions,omitempty"`
+	// Snippet and Highlighted show the text with the terms of the search highlighted
+	Snippet     Snippet   `json:"-"`
+	Highlighted []Segment `json:"-"`
 }
 
 type SearchResultValue struct {
 	Results []SearchResult `json:"results"`
+	// Offset is the offset the backend applied, missing when it does not page
+	Offset *int `json:"offset"`
 }
 
 type SearchResponseResult struct {
@@ -102,6 +145,33 @@ func (b *SearchResponseBody) GetResults() []SearchResult {
 	return nil
 }
 
+// CategoryHit is how many results of a search came from a category, and how
+// close the best of them was to the query
+type CategoryHit struct {
+	Category       string
+	Hits           int32
+	BestSimilarity float64
+}
+
+// CategoryHits groups the results of a search by category, in the order the
+// categories first appear. Keyword matches count as hits, but their ranks are
+// no similarities.
+func CategoryHits(results []SearchResult) []CategoryHit {
+	hits := []CategoryHit{}
+	for _, result := range results {
+		idx := slices.IndexFunc(hits, func(h CategoryHit) bool { return h.Category == result.Category })
+		if idx < 0 {
+			hits = append(hits, CategoryHit{Category: result.Category})
+			idx = len(hits) - 1
+		}
+		hits[idx].Hits++
+		if result.Retriever != RetrieverKeyword {
+			hits[idx].BestSimilarity = max(hits[idx].BestSimilarity, result.Similarity)
+		}
+	}
+	return hits
+}
+
 func ProcessFile(fileInput InputFileEvent) (*FilesResponseBody, error) {
 	requestBody := FilesRequestBody{StartEvent: fileInput, Context: map[string]any{}, HandlerId: ""}
 	apiKey := os.Getenv("LLAMA_CLOUD_API_KEY")
@@ -198,3 +268,245 @@ func ProcessSearch(searchInput SearchInputEvent) (*SearchResponseBody, error) {
 	}
 	return &response, nil
 }
+
+type ReindexRequestBody struct {
+	StartEvent ReindexInputEvent `json:"start_event"`
+	Context    map[string]any    `json:"context"`
+	HandlerId  string            `json:"handler_id"`
+}
+
+type ReindexInputEvent struct {
+	Username string              `json:"username"`
+	FileName string              `json:"file_name"`
+	Category string              `json:"category"`
+	Summary  string              `json:"summary"`
+	Faqs     []QuestionAndAnswer `json:"faqs"`
+}
+
+type ReindexResultValue struct {
+	Success bool    `json:"success"`
+	Error   *string `json:"error"`
+}
+
+type ReindexResponseResult struct {
+	Value         ReindexResultValue `json:"value"`
+	QualifiedName string             `json:"qualified_name"`
+	Type          string             `json:"type"`
+	Types         []string           `json:"types"`
+}
+
+type ReindexResponseBody struct {
+	HandlerId    string                 `json:"handler_id"`
+	WorkflowName string                 `json:"workflow_name"`
+	RunId        string                 `json:"run_id"`
+	Status       string                 `json:"status"`
+	StartedAt    *string                `json:"started_at"`
+	UpdatedAt    *string                `json:"updated_at"`
+	CompletedAt  *string                `json:"completed_at"`
+	Error        *string                `json:"error"`
+	Result       *ReindexResponseResult `json:"result"`
+}
+
+func (b *ReindexResponseBody) GetErrorString() *string {
+	if b.Result != nil {
+		return b.Result.Value.Error
+	}
+	return b.Error
+}
+
+// runWorkflow sends a start event to a deployed workflow endpoint and decodes
+// its response into the given value
+func runWorkflow(apiEndpoint string, requestBody any, response any) error {
+	apiKey := os.Getenv("LLAMA_CLOUD_API_KEY")
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	req, err := http.NewRequestWithContext(ctx, "POST", apiEndpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, response)
+}
+
+// ProcessReindex replaces the indexed summary and FAQs of a note with the given ones
+func ProcessReindex(reindexInput ReindexInputEvent) (*ReindexResponseBody, error) {
+	requestBody := ReindexRequestBody{StartEvent: reindexInput, Context: map[string]any{}, HandlerId: ""}
+	var response ReindexResponseBody
+	err := runWorkflow(os.Getenv("REINDEX_API_ENDPOINT"), requestBody, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+type CleanupRequestBody struct {
+	StartEvent CleanupInputEvent `json:"start_event"`
+	Context    map[string]any    `json:"context"`
+	HandlerId  string            `json:"handler_id"`
+}
+
+type CleanupInputEvent struct {
+	Username string `json:"username"`
+	FileName string `json:"file_name"`
+}
+
+type CleanupResultValue struct {
+	Success bool    `json:"success"`
+	Error   *string `json:"error"`
+}
+
+type CleanupResponseResult struct {
+	Value         CleanupResultValue `json:"value"`
+	QualifiedName string             `json:"qualified_name"`
+	Type          string             `json:"type"`
+	Types         []string           `json:"types"`
+}
+
+type CleanupResponseBody struct {
+	HandlerId    string                 `json:"handler_id"`
+	WorkflowName string                 `json:"workflow_name"`
+	RunId        string                 `json:"run_id"`
+	Status       string                 `json:"status"`
+	StartedAt    *string                `json:"started_at"`
+	UpdatedAt    *string                `json:"updated_at"`
+	CompletedAt  *string                `json:"completed_at"`
+	Error        *string                `json:"error"`
+	Result       *CleanupResponseResult `json:"result"`
+}
+
+func (b *CleanupResponseBody) GetErrorString() *string {
+	if b.Result != nil {
+		return b.Result.Value.Error
+	}
+	return b.Error
+}
+
+// ProcessCleanup removes the indexed summary and FAQs of a deleted note from the vector store
+func ProcessCleanup(cleanupInput CleanupInputEvent) (*CleanupResponseBody, error) {
+	requestBody := CleanupRequestBody{StartEvent: cleanupInput, Context: map[string]any{}, HandlerId: ""}
+	var response CleanupResponseBody
+	err := runWorkflow(os.Getenv("CLEANUP_API_ENDPOINT"), requestBody, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DeleteLlamaCloudFile deletes an uploaded file from LlamaCloud. Files that
+// do not exist anymore are considered deleted.
+func DeleteLlamaCloudFile(fileId string) error {
+	apiKey := os.Getenv("LLAMA_CLOUD_API_KEY")
+	ctx := context.Background()
+	req, err := http.NewRequestWithContext(ctx, "DELETE", files.LlamaCloudBaseUrl()+"/api/v1/files/"+url.PathEscape(fileId), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode/100 == 2 {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+}
+
+type ClassifyRequestBody struct {
+	StartEvent ClassifyInputEvent `json:"start_event"`
+	Context    map[string]any     `json:"context"`
+	HandlerId  string             `json:"handler_id"`
+}
+
+type ClassifyRule struct {
+	RuleType        string `json:"rule_type"`
+	RuleDescription string `json:"rule_description"`
+	ID              *int32 `json:"id"`
+	ParentID        *int32 `json:"parent_id"`
+}
+
+type ClassifyInputEvent struct {
+	FileId string         `json:"file_id"`
+	Rules  []ClassifyRule `json:"rules"`
+}
+
+type ClassifyResultValue struct {
+	Success    bool     `json:"success"`
+	Error      *string  `json:"error"`
+	Category   *string  `json:"category"`
+	Confidence *float64 `json:"confidence"`
+	Reasoning  *string  `json:"reasoning"`
+}
+
+type ClassifyResponseResult struct {
+	Value         ClassifyResultValue `json:"value"`
+	QualifiedName string              `json:"qualified_name"`
+	Type          string              `json:"type"`
+	Types         []string            `json:"types"`
+}
+
+type ClassifyResponseBody struct {
+	HandlerId    string                  `json:"handler_id"`
+	WorkflowName string                  `json:"workflow_name"`
+	RunId        string                  `json:"run_id"`
+	Status       string                  `json:"status"`
+	StartedAt    *string                 `json:"started_at"`
+	UpdatedAt    *string                 `json:"updated_at"`
+	CompletedAt  *string                 `json:"completed_at"`
+	Error        *string                 `json:"error"`
+	Result       *ClassifyResponseResult `json:"result"`
+}
+
+func (b *ClassifyResponseBody) GetErrorString() *string {
+	if b.Result != nil {
+		return b.Result.Value.Error
+	}
+	return b.Error
+}
+
+// GetClassification returns the outcome of a dry run classification
+func (b *ClassifyResponseBody) GetClassification() ClassifyResultValue {
+	if b.Result != nil {
+		return b.Result.Value
+	}
+	return ClassifyResultValue{}
+}
+
+// ProcessClassify classifies an uploaded file against the given categories,
+// without storing the file or the result
+func ProcessClassify(classifyInput ClassifyInputEvent) (*ClassifyResponseBody, error) {
+	requestBody := ClassifyRequestBody{StartEvent: classifyInput, Context: map[string]any{}, HandlerId: ""}
+	var response ClassifyResponseBody
+	err := runWorkflow(os.Getenv("CLASSIFY_API_ENDPOINT"), requestBody, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
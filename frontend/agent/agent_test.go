@@ -1,7 +1,11 @@
 package agent
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/run-llama/study-llama/frontend/files"
@@ -16,11 +20,11 @@ func TestProcessFile(t *testing.T) {
 	}
 	file := "../testfiles/the-future-of-vibe-coding.pdf"
 	src, _ := os.Open(file)
-	fileId, err := files.UploadFile(src, file)
+	uploaded, err := files.UploadFile(src, file)
 	if err != nil {
-		t.Errorf("Expected no error while uploading the file, got %s", err.Error())
+		t.Fatalf("Expected no error while uploading the file, got %s", err.Error())
 	}
-	inputEvent := InputFileEvent{FileName: file, FileId: fileId, Username: user}
+	inputEvent := InputFileEvent{FileName: file, FileId: uploaded.ID, Username: user}
 	res, err := ProcessFile(inputEvent)
 	if err != nil {
 		t.Errorf("Expected no error while processing the file, got %s", err.Error())
@@ -48,3 +52,151 @@ func TestProcessSearch(t *testing.T) {
 		t.Errorf("Expecting results from the search, got none")
 	}
 }
+
+func TestGetStudyNotes(t *testing.T) {
+	body := `{"status": "completed", "result": {"value": {"success": true, "error": null, "summary": "A summary", "faqs": [{"question": "Why?", "answer": "Because."}]}}}`
+	var response FilesResponseBody
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Not expecting an error while decoding the response, got %s", err.Error())
+	}
+	summary, faqs := response.GetStudyNotes()
+	if summary == nil || *summary != "A summary" {
+		t.Errorf("Expecting the summary to be decoded, got %v", summary)
+	}
+	if len(faqs) != 1 || faqs[0].Question != "Why?" || faqs[0].Answer != "Because." {
+		t.Errorf("Expecting one decoded FAQ, got %v", faqs)
+	}
+	empty := FilesResponseBody{}
+	if summary, faqs := empty.GetStudyNotes(); summary != nil || faqs != nil {
+		t.Error("Expecting no study notes when the workflow returned no result")
+	}
+}
+
+func TestProcessReindex(t *testing.T) {
+	var received ReindexRequestBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "completed", "result": {"value": {"success": true, "error": null}}}`))
+	}))
+	defer server.Close()
+	t.Setenv("REINDEX_API_ENDPOINT", server.URL)
+	inputEvent := ReindexInputEvent{Username: "testuser", FileName: "notes.pdf", Category: "biology", Summary: "A summary", Faqs: []QuestionAndAnswer{{Question: "Why?", Answer: "Because."}}}
+	res, err := ProcessReindex(inputEvent)
+	if err != nil {
+		t.Fatalf("Expected no error while re-indexing, got %s", err.Error())
+	}
+	if res.GetErrorString() != nil {
+		t.Errorf("Expected no error from the backend, got %s", *res.GetErrorString())
+	}
+	if received.StartEvent.FileName != "notes.pdf" || len(received.StartEvent.Faqs) != 1 {
+		t.Errorf("Expected the start event to be forwarded to the backend, got %v", received.StartEvent)
+	}
+}
+
+func TestCategoryHits(t *testing.T) {
+	results := []SearchResult{
+		{Category: "genetics", Similarity: 0.81},
+		{Category: "biology", Similarity: 0.9},
+		{Category: "genetics", Similarity: 0.86},
+	}
+	hits := CategoryHits(results)
+	if len(hits) != 2 || hits[0].Category != "genetics" || hits[0].Hits != 2 || hits[0].BestSimilarity != 0.86 {
+		t.Errorf("Unexpected category hits %+v", hits)
+	}
+	hits = CategoryHits([]SearchResult{{Category: "biology", Similarity: 0.95, Retriever: RetrieverKeyword}})
+	if len(hits) != 1 || hits[0].Hits != 1 || hits[0].BestSimilarity != 0 {
+		t.Errorf("Expecting keyword matches to count without a similarity, got %+v", hits)
+	}
+	if len(CategoryHits(nil)) != 0 {
+		t.Error("Expecting no hits without results")
+	}
+}
+
+func TestProcessCleanup(t *testing.T) {
+	var received CleanupRequestBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "completed", "result": {"value": {"success": true, "error": null}}}`))
+	}))
+	defer server.Close()
+	t.Setenv("CLEANUP_API_ENDPOINT", server.URL)
+	res, err := ProcessCleanup(CleanupInputEvent{Username: "testuser", FileName: "notes.pdf"})
+	if err != nil {
+		t.Fatalf("Expected no error while cleaning up, got %s", err.Error())
+	}
+	if res.GetErrorString() != nil {
+		t.Errorf("Expected no error from the backend, got %s", *res.GetErrorString())
+	}
+	if received.StartEvent.Username != "testuser" || received.StartEvent.FileName != "notes.pdf" {
+		t.Errorf("Expected the start event to be forwarded to the backend, got %v", received.StartEvent)
+	}
+}
+
+func TestDeleteLlamaCloudFile(t *testing.T) {
+	files := map[string]bool{"file-1": true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fileId := strings.TrimPrefix(r.URL.Path, "/api/v1/files/")
+		if fileId == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !files[fileId] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(files, fileId)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Setenv("LLAMA_CLOUD_BASE_URL", server.URL)
+	t.Setenv("LLAMA_CLOUD_API_KEY", "test-key")
+	if err := DeleteLlamaCloudFile("file-1"); err != nil {
+		t.Errorf("Expected no error while deleting an existing file, got %s", err.Error())
+	}
+	if files["file-1"] {
+		t.Error("Expected the file to be deleted")
+	}
+	if err := DeleteLlamaCloudFile("file-1"); err != nil {
+		t.Errorf("Expected a missing file to be considered deleted, got %s", err.Error())
+	}
+	if err := DeleteLlamaCloudFile("broken"); err == nil {
+		t.Error("Expected an error when LlamaCloud fails")
+	}
+}
+
+func TestProcessClassify(t *testing.T) {
+	var received ClassifyRequestBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "completed", "result": {"value": {"success": true, "error": null, "category": "biology", "confidence": 0.9, "reasoning": "Mentions cells"}}}`))
+	}))
+	defer server.Close()
+	t.Setenv("CLASSIFY_API_ENDPOINT", server.URL)
+	res, err := ProcessClassify(ClassifyInputEvent{FileId: "file-1", Rules: []ClassifyRule{{RuleType: "biology", RuleDescription: "Cells"}}})
+	if err != nil {
+		t.Fatalf("Expected no error while classifying, got %s", err.Error())
+	}
+	if res.GetErrorString() != nil {
+		t.Errorf("Expected no error from the backend, got %s", *res.GetErrorString())
+	}
+	classification := res.GetClassification()
+	if classification.Category == nil || *classification.Category != "biology" || classification.Confidence == nil || *classification.Confidence != 0.9 {
+		t.Errorf("Unexpected classification %+v", classification)
+	}
+	if received.StartEvent.FileId != "file-1" || len(received.StartEvent.Rules) != 1 {
+		t.Errorf("Expected the start event to be forwarded to the backend, got %v", received.StartEvent)
+	}
+}
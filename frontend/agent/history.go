@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+// NewHistoryEntry describes a search as it is stored in the history, with the
+// filters as the user picked them
+func NewHistoryEntry(event SearchInputEvent, resultCount int) filesdb.CreateSearchHistoryEntryParams {
+	stored := newStoredSearch(event)
+	return filesdb.CreateSearchHistoryEntryParams{Username: stored.Username, SearchType: stored.SearchType, SearchInput: stored.SearchInput, FileName: stored.FileName, Category: stored.Category, CourseID: stored.CourseID, Tags: stored.Tags, ResultCount: int32(resultCount), FileNames: stored.FileNames, Categories: stored.Categories, ExcludedFileNames: stored.ExcludedFileNames, ExcludedCategories: stored.ExcludedCategories}
+}
+
+// HistorySearchEvent rebuilds the search of a history entry, to run it again
+func HistorySearchEvent(entry filesdb.SearchHistory) SearchInputEvent {
+	return storedSearch{Username: entry.Username, SearchType: entry.SearchType, SearchInput: entry.SearchInput, FileName: entry.FileName, Category: entry.Category, CourseID: entry.CourseID, Tags: entry.Tags, FileNames: entry.FileNames, Categories: entry.Categories, ExcludedFileNames: entry.ExcludedFileNames, ExcludedCategories: entry.ExcludedCategories}.event()
+}
+
+// storedSearch is a search with its filters as the history and the saved
+// searches store them
+type storedSearch struct {
+	Username           string
+	SearchType         string
+	SearchInput        string
+	FileName           pgtype.Text
+	Category           pgtype.Text
+	CourseID           pgtype.Int4
+	Tags               []string
+	FileNames          []string
+	Categories         []string
+	ExcludedFileNames  []string
+	ExcludedCategories []string
+}
+
+// newStoredSearch describes a search as it is stored, with the filters as the
+// user picked them
+func newStoredSearch(event SearchInputEvent) storedSearch {
+	stored := storedSearch{Username: event.Username, SearchType: event.SearchType, SearchInput: strings.TrimSpace(event.SearchInput), Tags: storedList(event.Tags), FileNames: storedList(event.FileNames), Categories: storedList(event.Categories), ExcludedFileNames: storedList(event.ExcludedFileNames), ExcludedCategories: storedList(event.ExcludedCategories)}
+	if event.FileName != nil {
+		stored.FileName = pgtype.Text{String: *event.FileName, Valid: true}
+	}
+	if event.Category != nil {
+		stored.Category = pgtype.Text{String: *event.Category, Valid: true}
+	}
+	if event.CourseID != nil {
+		stored.CourseID = pgtype.Int4{Int32: *event.CourseID, Valid: true}
+	}
+	return stored
+}
+
+// event rebuilds a stored search as sent to the search backend
+func (s storedSearch) event() SearchInputEvent {
+	event := SearchInputEvent{SearchType: s.SearchType, SearchInput: s.SearchInput, Username: s.Username, Tags: eventList(s.Tags), FileNames: eventList(s.FileNames), Categories: eventList(s.Categories), ExcludedFileNames: eventList(s.ExcludedFileNames), ExcludedCategories: eventList(s.ExcludedCategories)}
+	if s.FileName.Valid {
+		event.FileName = &s.FileName.String
+	}
+	if s.Category.Valid {
+		event.Category = &s.Category.String
+	}
+	if s.CourseID.Valid {
+		event.CourseID = &s.CourseID.Int32
+	}
+	return event
+}
+
+// storedList is a list filter as stored, the columns not being nullable
+func storedList(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// eventList is a stored list filter as sent to the search backend, which
+// takes no list as no filter
+func eventList(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
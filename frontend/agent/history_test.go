@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+func TestSearchHistoryRoundTrip(t *testing.T) {
+	fileName := "cells.pdf"
+	category := "biology"
+	course := int32(4)
+	event := SearchInputEvent{SearchType: "faqs", SearchInput: " What is a cell? ", Username: "testuser", FileName: &fileName, Category: &category, CourseID: &course, Tags: []string{"exam-1"}}
+	entry := NewHistoryEntry(event, 3)
+	if entry.SearchInput != "What is a cell?" || entry.ResultCount != 3 || !entry.FileName.Valid || entry.Category.String != "biology" || entry.CourseID.Int32 != 4 {
+		t.Errorf("Unexpected history entry %+v", entry)
+	}
+
+	rerun := HistorySearchEvent(filesdb.SearchHistory{Username: entry.Username, SearchType: entry.SearchType, SearchInput: entry.SearchInput, FileName: entry.FileName, Category: entry.Category, CourseID: entry.CourseID, Tags: entry.Tags})
+	if rerun.SearchType != "faqs" || *rerun.FileName != "cells.pdf" || *rerun.Category != "biology" || *rerun.CourseID != 4 || !slices.Equal(rerun.Tags, []string{"exam-1"}) {
+		t.Errorf("Unexpected search event %+v", rerun)
+	}
+}
+
+func TestSearchHistoryWithoutFilters(t *testing.T) {
+	entry := NewHistoryEntry(SearchInputEvent{SearchType: "summary", SearchInput: "mitosis", Username: "testuser"}, 0)
+	if entry.FileName.Valid || entry.Category.Valid || entry.CourseID.Valid {
+		t.Errorf("Expecting no filters, got %+v", entry)
+	}
+	if entry.Tags == nil {
+		t.Error("Expecting the tags to never be nil, since the column is not nullable")
+	}
+	rerun := HistorySearchEvent(filesdb.SearchHistory{SearchType: "summary", SearchInput: "mitosis", Tags: []string{}})
+	if rerun.FileName != nil || rerun.Category != nil || rerun.CourseID != nil || rerun.Tags != nil {
+		t.Errorf("Expecting no filters, got %+v", rerun)
+	}
+}
+
+func TestSearchHistoryListFilters(t *testing.T) {
+	event := SearchInputEvent{SearchType: "all", SearchInput: "enzymes", Username: "testuser", FileNames: []string{"cells.pdf", "metabolism.pdf"}, Categories: []string{"biology"}, ExcludedFileNames: []string{"draft.md"}}
+	entry := NewHistoryEntry(event, 1)
+	if len(entry.FileNames) != 2 || len(entry.Categories) != 1 || len(entry.ExcludedFileNames) != 1 || entry.ExcludedCategories == nil {
+		t.Errorf("Unexpected history entry %+v", entry)
+	}
+	rerun := HistorySearchEvent(filesdb.SearchHistory{SearchType: entry.SearchType, SearchInput: entry.SearchInput, FileNames: entry.FileNames, Categories: entry.Categories, ExcludedFileNames: entry.ExcludedFileNames, ExcludedCategories: entry.ExcludedCategories})
+	if !slices.Equal(rerun.FileNames, event.FileNames) || !slices.Equal(rerun.Categories, event.Categories) || !slices.Equal(rerun.ExcludedFileNames, event.ExcludedFileNames) || rerun.ExcludedCategories != nil {
+		t.Errorf("Unexpected search event %+v", rerun)
+	}
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+const (
+	// SearchTypeHybrid fuses the vector search of the summaries and the FAQs
+	// with the keyword search, catching the exact terms the vectors miss
+	SearchTypeHybrid = "hybrid"
+	// RetrieverVector marks the rankings of the search backend
+	RetrieverVector = "vector"
+	// RetrieverHybrid marks the results fused from several rankings
+	RetrieverHybrid = "hybrid"
+	// rrfK dampens the lead of the top ranks in the reciprocal rank fusion
+	rrfK = 60
+	// defaultRetrieverWeight is the weight of a retriever in the fusion when
+	// none is configured
+	defaultRetrieverWeight = 1.0
+)
+
+// Contribution is the rank a retriever gave to a fused search result
+type Contribution struct {
+	Retriever  string  `json:"retriever"`
+	Rank       int     `json:"rank"`
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// Ranking is the results of a retriever, from the best to the worst, along
+// with its weight in the fusion
+type Ranking struct {
+	Retriever string
+	Weight    float64
+	Results   []SearchResult
+}
+
+// HybridWeights returns the weights of the vector and of the keyword rankings
+// in a hybrid search, configured through HYBRID_VECTOR_WEIGHT and
+// HYBRID_KEYWORD_WEIGHT
+func HybridWeights() (float64, float64) {
+	return retrieverWeight("HYBRID_VECTOR_WEIGHT"), retrieverWeight("HYBRID_KEYWORD_WEIGHT")
+}
+
+func retrieverWeight(key string) float64 {
+	weight, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || weight < 0 {
+		return defaultRetrieverWeight
+	}
+	return weight
+}
+
+// FuseRankings fuses rankings with reciprocal rank fusion: a result scores the
+// weighted sum of 1/(k+rank) over the rankings it appears in, results sharing a
+// note and a text being the same. The fused results keep the best similarity
+// the vector rankings gave them and list the contributions of the retrievers.
+func FuseRankings(rankings ...Ranking) []SearchResult {
+	type key struct{ fileName, text string }
+	positions := map[key]int{}
+	fused := []SearchResult{}
+	for _, ranking := range rankings {
+		for idx, result := range ranking.Results {
+			k := key{result.FileName, result.Text}
+			pos, ok := positions[k]
+			if !ok {
+				fused = append(fused, SearchResult{ResultType: result.ResultType, Text: result.Text, FileName: result.FileName, Category: result.Category, Retriever: RetrieverHybrid})
+				pos = len(fused) - 1
+				positions[k] = pos
+			}
+			// a ranking listing a result twice only counts its best rank
+			if slices.ContainsFunc(fused[pos].Contributions, func(c Contribution) bool { return c.Retriever == ranking.Retriever }) {
+				continue
+			}
+			contribution := Contribution{Retriever: ranking.Retriever, Rank: idx + 1}
+			if ranking.Retriever == RetrieverVector {
+				contribution.Similarity = result.Similarity
+				fused[pos].Similarity = max(fused[pos].Similarity, result.Similarity)
+			}
+			fused[pos].Score += ranking.Weight / float64(rrfK+idx+1)
+			fused[pos].Contributions = append(fused[pos].Contributions, contribution)
+		}
+	}
+	slices.SortStableFunc(fused, func(a, b SearchResult) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return fused
+}
+
+// ProcessSearchHybrid runs the vector search of the summaries and the FAQs
+// and the keyword search concurrently, fusing their rankings. Like
+// ProcessSearchAll, both ask for every result up to the end of the requested
+// page and the response does not carry an offset, the fused results being
+// paged as a whole.
+func ProcessSearchHybrid(ctx context.Context, queries *filesdb.Queries, searchInput SearchInputEvent) (*SearchResponseBody, error) {
+	search := searchInput
+	search.TopK = searchInput.Offset + searchInput.PageSize()
+	search.Offset = 0
+	var vector, keyword *SearchResponseBody
+	var vectorErr, keywordErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		vector, vectorErr = ProcessSearchAll(search)
+	}()
+	go func() {
+		defer wg.Done()
+		keyword, keywordErr = KeywordSearch(ctx, queries, search)
+	}()
+	wg.Wait()
+	if err := errors.Join(vectorErr, keywordErr); err != nil {
+		return nil, err
+	}
+	// the backend may not apply the similarity threshold itself
+	threshold := searchInput.Threshold()
+	vectorResults := slices.DeleteFunc(vector.GetResults(), func(r SearchResult) bool { return r.Similarity < threshold })
+	vectorWeight, keywordWeight := HybridWeights()
+	fused := FuseRankings(Ranking{RetrieverVector, vectorWeight, vectorResults}, Ranking{RetrieverKeyword, keywordWeight, keyword.GetResults()})
+	return &SearchResponseBody{Status: "completed", Result: &SearchResponseResult{Value: SearchResultValue{Results: fused}}}, nil
+}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHybridWeights(t *testing.T) {
+	testCases := []struct {
+		vectorEnv, keywordEnv string
+		vector, keyword       float64
+	}{
+		{"", "", 1, 1},
+		{"0.7", "0.3", 0.7, 0.3},
+		{"-1", "heavy", 1, 1},
+		{"0", "2", 0, 2},
+	}
+	for _, tc := range testCases {
+		t.Setenv("HYBRID_VECTOR_WEIGHT", tc.vectorEnv)
+		t.Setenv("HYBRID_KEYWORD_WEIGHT", tc.keywordEnv)
+		if vector, keyword := HybridWeights(); vector != tc.vector || keyword != tc.keyword {
+			t.Errorf("HybridWeights() with %q and %q = %v, %v, expecting %v, %v", tc.vectorEnv, tc.keywordEnv, vector, keyword, tc.vector, tc.keyword)
+		}
+	}
+}
+
+func TestFuseRankings(t *testing.T) {
+	vector := []SearchResult{
+		{ResultType: "summary", Text: "Cells divide", FileName: "cells.pdf", Similarity: 0.9},
+		{ResultType: "answer", Text: "BRCA1 repairs DNA", FileName: "genetics.pdf", Similarity: 0.8},
+	}
+	keyword := []SearchResult{
+		{ResultType: "answer", Text: "BRCA1 repairs DNA", FileName: "genetics.pdf", Similarity: 0.3, Retriever: RetrieverKeyword},
+		{ResultType: "answer", Text: "TP53 suppresses tumors", FileName: "genetics.pdf", Similarity: 0.2, Retriever: RetrieverKeyword},
+	}
+	fused := FuseRankings(Ranking{RetrieverVector, 1, vector}, Ranking{RetrieverKeyword, 1, keyword})
+	if len(fused) != 3 {
+		t.Fatalf("Expecting 3 fused results, got %+v", fused)
+	}
+	if fused[0].Text != "BRCA1 repairs DNA" || len(fused[0].Contributions) != 2 || math.Abs(fused[0].Score-(1.0/62+1.0/61)) > 1e-12 {
+		t.Errorf("Expecting the result found by both retrievers first, got %+v", fused[0])
+	}
+	if fused[0].Similarity != 0.8 || fused[0].Retriever != RetrieverHybrid {
+		t.Errorf("Expecting the fused result to keep its vector similarity, got %+v", fused[0])
+	}
+	if fused[1].Text != "Cells divide" || fused[2].Text != "TP53 suppresses tumors" || fused[2].Similarity != 0 {
+		t.Errorf("Expecting the results found once in the order of their ranks, got %+v", fused)
+	}
+	if c := fused[2].Contributions; len(c) != 1 || c[0].Retriever != RetrieverKeyword || c[0].Rank != 2 {
+		t.Errorf("Unexpected contributions %+v", c)
+	}
+
+	fused = FuseRankings(Ranking{RetrieverVector, 0.1, vector}, Ranking{RetrieverKeyword, 1, keyword})
+	if fused[0].Text != "BRCA1 repairs DNA" || fused[1].Text != "TP53 suppresses tumors" {
+		t.Errorf("Expecting the weights to favor the keyword ranking, got %+v", fused)
+	}
+}
+
+func TestPageOfFusedResults(t *testing.T) {
+	response := &SearchResponseBody{Result: &SearchResponseResult{Value: SearchResultValue{Results: []SearchResult{
+		{Text: "BRCA1", Score: 0.03, Retriever: RetrieverHybrid},
+		{Text: "TP53", Score: 0.02, Retriever: RetrieverHybrid},
+		{Text: "Cells", Score: 0.01, Similarity: 0.9, Retriever: RetrieverHybrid},
+	}}}}
+	results, more := response.Page(SearchInputEvent{TopK: 2, Offset: 1})
+	if len(results) != 2 || results[0].Text != "TP53" || more {
+		t.Errorf("Expecting the fused results to be paged locally regardless of the threshold, got %+v and more=%v", results, more)
+	}
+}
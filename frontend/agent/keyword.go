@@ -0,0 +1,64 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+const (
+	// SearchTypeKeyword searches the summaries and the FAQs for the exact
+	// keywords of the search, with the full-text index of the notes
+	SearchTypeKeyword = "keyword"
+	// RetrieverKeyword marks the results of the keyword search
+	RetrieverKeyword = "keyword"
+)
+
+// KeywordSearchParams describes a search as a full-text query of the notes,
+// with all of its filters. The single note and category filters join the lists
+// of notes and categories, which the query takes as no filter when empty.
+func KeywordSearchParams(event SearchInputEvent) filesdb.KeywordSearchNotesParams {
+	params := filesdb.KeywordSearchNotesParams{
+		Query:              strings.TrimSpace(event.SearchInput),
+		Summaries:          event.SearchType != SearchTypeFaqs,
+		Faqs:               event.SearchType != SearchTypeSummary,
+		Username:           event.Username,
+		FileNames:          storedList(event.FileNames),
+		Categories:         storedList(event.Categories),
+		ExcludedFileNames:  storedList(event.ExcludedFileNames),
+		ExcludedCategories: storedList(event.ExcludedCategories),
+		Tags:               storedList(event.Tags),
+		MaxResults:         int32(event.PageSize()),
+		Skip:               int32(event.Offset),
+	}
+	if event.CourseID != nil {
+		params.CourseID = pgtype.Int4{Int32: *event.CourseID, Valid: true}
+	}
+	if event.FileName != nil && !slices.Contains(params.FileNames, *event.FileName) {
+		params.FileNames = append(slices.Clone(params.FileNames), *event.FileName)
+	}
+	if event.Category != nil && !slices.Contains(params.Categories, *event.Category) {
+		params.Categories = append(slices.Clone(params.Categories), *event.Category)
+	}
+	return params
+}
+
+// KeywordSearch runs a search on the full-text index of the notes, when the
+// search backend is down or the exact keywords matter. The results take the
+// shape of those of the backend, scored by their normalized rank, and the
+// response carries the offset of a backend that pages.
+func KeywordSearch(ctx context.Context, queries *filesdb.Queries, searchInput SearchInputEvent) (*SearchResponseBody, error) {
+	rows, err := queries.KeywordSearchNotes(ctx, KeywordSearchParams(searchInput))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, SearchResult{ResultType: row.ResultType, Text: row.Text, Similarity: row.Rank, FileName: row.FileName, Category: row.FileCategory.String, Retriever: RetrieverKeyword})
+	}
+	offset := searchInput.Offset
+	return &SearchResponseBody{Status: "completed", Result: &SearchResponseResult{Value: SearchResultValue{Results: results, Offset: &offset}}}, nil
+}
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestKeywordSearchParams(t *testing.T) {
+	fileName := "cells.pdf"
+	category := "biology"
+	event := SearchInputEvent{SearchType: SearchTypeKeyword, SearchInput: " \"cell cycle\" ", Username: "testuser", FileName: &fileName, Category: &category, Categories: []string{"biology", "genetics"}, TopK: 6, Offset: 10}
+	params := KeywordSearchParams(event)
+	if params.Query != "\"cell cycle\"" || !params.Summaries || !params.Faqs || params.Username != "testuser" || params.MaxResults != 6 || params.Skip != 10 {
+		t.Errorf("Unexpected params %+v", params)
+	}
+	if !slices.Equal(params.FileNames, []string{"cells.pdf"}) || !slices.Equal(params.Categories, []string{"biology", "genetics"}) {
+		t.Errorf("Expecting the single filters to join the lists, got %+v and %+v", params.FileNames, params.Categories)
+	}
+	if params.ExcludedFileNames == nil || params.ExcludedCategories == nil || params.Tags == nil || params.CourseID.Valid {
+		t.Errorf("Expecting empty rather than nil lists and no course, got %+v", params)
+	}
+	if !slices.Equal(event.Categories, []string{"biology", "genetics"}) {
+		t.Errorf("Expecting the event to be left untouched, got %+v", event.Categories)
+	}
+
+	courseID := int32(3)
+	if params := KeywordSearchParams(SearchInputEvent{Tags: []string{"exam"}, CourseID: &courseID}); params.CourseID.Int32 != 3 || !params.CourseID.Valid || !slices.Equal(params.Tags, []string{"exam"}) {
+		t.Errorf("Expecting the course and tag filters, got %+v", params)
+	}
+	if params := KeywordSearchParams(SearchInputEvent{SearchType: SearchTypeSummary}); !params.Summaries || params.Faqs || params.MaxResults != DefaultTopK {
+		t.Errorf("Expecting a summary search to skip the FAQs, got %+v", params)
+	}
+	if params := KeywordSearchParams(SearchInputEvent{SearchType: SearchTypeFaqs}); params.Summaries || !params.Faqs {
+		t.Errorf("Expecting a FAQs search to skip the summaries, got %+v", params)
+	}
+}
+
+func TestPageKeepsKeywordMatches(t *testing.T) {
+	offset := 0
+	response := &SearchResponseBody{Result: &SearchResponseResult{Value: SearchResultValue{Offset: &offset, Results: []SearchResult{
+		{Text: "mitosis", Similarity: 0.1, Retriever: RetrieverKeyword},
+		{Text: "meiosis", Similarity: 0.05, Retriever: RetrieverKeyword},
+	}}}}
+	if results, more := response.Page(SearchInputEvent{TopK: 1}); len(results) != 1 || !more {
+		t.Errorf("Expecting keyword matches to be paged regardless of the threshold, got %+v and more=%v", results, more)
+	}
+}
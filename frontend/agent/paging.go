@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"strconv"
+)
+
+const (
+	// DefaultTopK is the number of results shown per page
+	DefaultTopK = 10
+	// MaxTopK is the largest page of results a search can ask for
+	MaxTopK = 50
+	// DefaultMinSimilarity is the similarity below which results are left out
+	DefaultMinSimilarity = 0.75
+	// LowestMinSimilarity is the lowest similarity threshold a search can ask for
+	LowestMinSimilarity = 0.5
+)
+
+// PageSizes are the page sizes offered in the search form
+var PageSizes = []int{5, 10, 20, 50}
+
+// ParsePaging reads the page size, similarity threshold and offset of a search
+// from form values, falling back to the defaults for missing or invalid values
+// and clamping the others to the allowed ranges
+func ParsePaging(topK string, minSimilarity string, offset string) (int, float64, int) {
+	k, err := strconv.Atoi(topK)
+	if err != nil || k <= 0 {
+		k = DefaultTopK
+	}
+	similarity, err := strconv.ParseFloat(minSimilarity, 64)
+	if err != nil {
+		similarity = DefaultMinSimilarity
+	}
+	start, err := strconv.Atoi(offset)
+	if err != nil {
+		start = 0
+	}
+	return min(k, MaxTopK), min(max(similarity, LowestMinSimilarity), 1), max(start, 0)
+}
+
+// PageSize is the number of results per page of a search
+func (e SearchInputEvent) PageSize() int {
+	if e.TopK <= 0 {
+		return DefaultTopK
+	}
+	return e.TopK
+}
+
+// Threshold is the similarity below which the results of a search are left out
+func (e SearchInputEvent) Threshold() float64 {
+	if e.MinSimilarity <= 0 {
+		return DefaultMinSimilarity
+	}
+	return e.MinSimilarity
+}
+
+// NextPage is the search loading the page of results after this one
+func (e SearchInputEvent) NextPage() SearchInputEvent {
+	next := e
+	next.Offset = e.Offset + e.PageSize()
+	return next
+}
+
+// Page returns the page of results of a search and whether more results
+// follow it. The search is expected to ask the backend for one result more
+// than the page size, telling whether there is a next page. Backends that do
+// not page ignore the offset, the page size and the threshold, and return all
+// the results above their own threshold at once: the results are then
+// filtered and paged here. Keyword matches and fused results are ranked
+// rather than scored by similarity, and are never left out by the threshold.
+func (b *SearchResponseBody) Page(event SearchInputEvent) ([]SearchResult, bool) {
+	threshold := event.Threshold()
+	results := []SearchResult{}
+	for _, result := range b.GetResults() {
+		if result.Retriever == RetrieverKeyword || result.Retriever == RetrieverHybrid || result.Similarity >= threshold {
+			results = append(results, result)
+		}
+	}
+	if b.Result == nil || b.Result.Value.Offset == nil {
+		results = results[min(event.Offset, len(results)):]
+	}
+	if size := event.PageSize(); len(results) > size {
+		return results[:size], true
+	}
+	return results, false
+}
@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"testing"
+)
+
+func TestParsePaging(t *testing.T) {
+	testCases := []struct {
+		topK, minSimilarity, offset string
+		k                           int
+		similarity                  float64
+		start                       int
+	}{
+		{"", "", "", DefaultTopK, DefaultMinSimilarity, 0},
+		{"20", "0.6", "40", 20, 0.6, 40},
+		{"500", "0.1", "-3", MaxTopK, LowestMinSimilarity, 0},
+		{"0", "2", "x", DefaultTopK, 1, 0},
+	}
+	for _, tc := range testCases {
+		k, similarity, start := ParsePaging(tc.topK, tc.minSimilarity, tc.offset)
+		if k != tc.k || similarity != tc.similarity || start != tc.start {
+			t.Errorf("ParsePaging(%q, %q, %q) = %d, %v, %d, expecting %d, %v, %d", tc.topK, tc.minSimilarity, tc.offset, k, similarity, start, tc.k, tc.similarity, tc.start)
+		}
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	next := SearchInputEvent{SearchInput: "mitosis", TopK: 5, Offset: 10}.NextPage()
+	if next.Offset != 15 || next.TopK != 5 || next.SearchInput != "mitosis" {
+		t.Errorf("Unexpected next page %+v", next)
+	}
+	if next := (SearchInputEvent{}).NextPage(); next.Offset != DefaultTopK {
+		t.Errorf("Expecting the default page size to be used, got offset %d", next.Offset)
+	}
+}
+
+func searchResponse(offset *int, similarities ...float64) *SearchResponseBody {
+	results := []SearchResult{}
+	for _, similarity := range similarities {
+		results = append(results, SearchResult{Text: "result", Similarity: similarity})
+	}
+	return &SearchResponseBody{Result: &SearchResponseResult{Value: SearchResultValue{Results: results, Offset: offset}}}
+}
+
+func TestPageFromPagingBackend(t *testing.T) {
+	offset := 2
+	event := SearchInputEvent{TopK: 2, MinSimilarity: 0.8, Offset: 2}
+	results, more := searchResponse(&offset, 0.9, 0.85, 0.82).Page(event)
+	if len(results) != 2 || !more {
+		t.Errorf("Expecting a full page followed by more results, got %d results and more=%v", len(results), more)
+	}
+	results, more = searchResponse(&offset, 0.9, 0.7).Page(event)
+	if len(results) != 1 || more {
+		t.Errorf("Expecting the last page with the results above the threshold, got %d results and more=%v", len(results), more)
+	}
+}
+
+func TestPageFromBackendIgnoringPaging(t *testing.T) {
+	response := searchResponse(nil, 0.95, 0.9, 0.85, 0.8, 0.76)
+	results, more := response.Page(SearchInputEvent{TopK: 2, MinSimilarity: 0.8})
+	if len(results) != 2 || results[0].Similarity != 0.95 || !more {
+		t.Errorf("Expecting the first page to be cut from all the results, got %+v and more=%v", results, more)
+	}
+	results, more = response.Page(SearchInputEvent{TopK: 2, MinSimilarity: 0.8, Offset: 2})
+	if len(results) != 2 || results[0].Similarity != 0.85 || more {
+		t.Errorf("Expecting the second page to be cut from the results above the threshold, got %+v and more=%v", results, more)
+	}
+	results, more = response.Page(SearchInputEvent{TopK: 2, Offset: 10})
+	if len(results) != 0 || more {
+		t.Errorf("Expecting no results past the end, got %+v and more=%v", results, more)
+	}
+	results, _ = (&SearchResponseBody{}).Page(SearchInputEvent{})
+	if results == nil || len(results) != 0 {
+		t.Errorf("Expecting an empty page for a response without results, got %+v", results)
+	}
+}
@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+// maxExcerptRunes is the length of the excerpt of the best match kept with an alert
+const maxExcerptRunes = 280
+
+// NewSavedSearch describes a search as it is saved to be alerted of new matches,
+// with the filters as the user picked them
+func NewSavedSearch(event SearchInputEvent) filesdb.CreateSavedSearchParams {
+	stored := newStoredSearch(event)
+	return filesdb.CreateSavedSearchParams{Username: stored.Username, SearchType: stored.SearchType, SearchInput: stored.SearchInput, FileName: stored.FileName, Category: stored.Category, CourseID: stored.CourseID, Tags: stored.Tags, FileNames: stored.FileNames, Categories: stored.Categories, ExcludedFileNames: stored.ExcludedFileNames, ExcludedCategories: stored.ExcludedCategories}
+}
+
+// SavedSearchEvent rebuilds a saved search, to run it
+func SavedSearchEvent(saved filesdb.SavedSearch) SearchInputEvent {
+	return storedSearch{Username: saved.Username, SearchType: saved.SearchType, SearchInput: saved.SearchInput, FileName: saved.FileName, Category: saved.Category, CourseID: saved.CourseID, Tags: saved.Tags, FileNames: saved.FileNames, Categories: saved.Categories, ExcludedFileNames: saved.ExcludedFileNames, ExcludedCategories: saved.ExcludedCategories}.event()
+}
+
+// NoteSearchEvent narrows a saved search down to a single note, to check
+// whether a new note matches it. It reports false when the saved search is
+// filtered on other notes or excludes the note, which it can then never match.
+func NoteSearchEvent(saved filesdb.SavedSearch, fileName string) (SearchInputEvent, bool) {
+	if saved.FileName.Valid && saved.FileName.String != fileName {
+		return SearchInputEvent{}, false
+	}
+	if len(saved.FileNames) > 0 && !slices.Contains(saved.FileNames, fileName) {
+		return SearchInputEvent{}, false
+	}
+	if slices.Contains(saved.ExcludedFileNames, fileName) {
+		return SearchInputEvent{}, false
+	}
+	event := SavedSearchEvent(saved)
+	event.FileName = &fileName
+	event.FileNames = nil
+	return event, true
+}
+
+// NewSearchAlert describes the results of a saved search coming from a new
+// note, keeping an excerpt of the best of them
+func NewSearchAlert(saved filesdb.SavedSearch, fileID int32, results []SearchResult) filesdb.UpsertSearchAlertParams {
+	alert := filesdb.UpsertSearchAlertParams{Username: saved.Username, SavedSearchID: saved.ID, FileID: fileID, Hits: int32(len(results))}
+	for idx, result := range results {
+		if idx == 0 || result.Similarity > alert.BestSimilarity {
+			alert.BestSimilarity = result.Similarity
+			alert.Excerpt = excerpt(result.Text)
+		}
+	}
+	return alert
+}
+
+func excerpt(text string) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= maxExcerptRunes {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:maxExcerptRunes-1])) + "…"
+}
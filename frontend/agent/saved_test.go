@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+func TestNoteSearchEvent(t *testing.T) {
+	saved := filesdb.SavedSearch{ID: 2, Username: "testuser", SearchType: "summary", SearchInput: "Krebs cycle", Category: pgtype.Text{String: "biology", Valid: true}, Tags: []string{}}
+	event, ok := NoteSearchEvent(saved, "metabolism.pdf")
+	if !ok {
+		t.Fatal("Expecting a saved search without a file filter to be checked on any note")
+	}
+	if *event.FileName != "metabolism.pdf" || *event.Category != "biology" || event.SearchInput != "Krebs cycle" || event.Tags != nil {
+		t.Errorf("Unexpected search event %+v", event)
+	}
+
+	saved.FileName = pgtype.Text{String: "cells.pdf", Valid: true}
+	if _, ok := NoteSearchEvent(saved, "metabolism.pdf"); ok {
+		t.Error("Expecting a saved search filtered on another note to be skipped")
+	}
+	if event, ok := NoteSearchEvent(saved, "cells.pdf"); !ok || *event.FileName != "cells.pdf" {
+		t.Errorf("Expecting a saved search filtered on the note to be checked, got %+v", event)
+	}
+
+	saved.FileName = pgtype.Text{}
+	saved.FileNames = []string{"cells.pdf", "metabolism.pdf"}
+	if event, ok := NoteSearchEvent(saved, "metabolism.pdf"); !ok || *event.FileName != "metabolism.pdf" || event.FileNames != nil {
+		t.Errorf("Expecting a saved search filtered on several notes to be narrowed to the note, got %+v", event)
+	}
+	if _, ok := NoteSearchEvent(saved, "genetics.pdf"); ok {
+		t.Error("Expecting a saved search filtered on other notes to be skipped")
+	}
+	saved.FileNames = []string{}
+	saved.ExcludedFileNames = []string{"genetics.pdf"}
+	if _, ok := NoteSearchEvent(saved, "genetics.pdf"); ok {
+		t.Error("Expecting a saved search excluding the note to be skipped")
+	}
+}
+
+func TestNewSearchAlert(t *testing.T) {
+	saved := filesdb.SavedSearch{ID: 2, Username: "testuser"}
+	results := []SearchResult{
+		{Text: "Glycolysis", Similarity: 0.8},
+		{Text: strings.Repeat("citrate ", 100), Similarity: 0.9},
+		{Text: "Electron transport", Similarity: 0.85},
+	}
+	alert := NewSearchAlert(saved, 7, results)
+	if alert.Username != "testuser" || alert.SavedSearchID != 2 || alert.FileID != 7 || alert.Hits != 3 || alert.BestSimilarity != 0.9 {
+		t.Errorf("Unexpected alert %+v", alert)
+	}
+	if !strings.HasPrefix(alert.Excerpt, "citrate") || !strings.HasSuffix(alert.Excerpt, "…") || len([]rune(alert.Excerpt)) > maxExcerptRunes {
+		t.Errorf("Expecting a truncated excerpt of the best match, got %q", alert.Excerpt)
+	}
+}
@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// snippetWords is the length in words of the passage shown for a result
+	snippetWords = 40
+	// snippetContext is how many words a passage keeps before its first match
+	snippetContext = 8
+)
+
+// stopWords are left out of the highlighted terms of a search
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"do": true, "does": true, "for": true, "from": true, "how": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "what": true,
+	"when": true, "where": true, "which": true, "who": true, "why": true, "with": true,
+}
+
+// Segment is a piece of the text of a search result, matching the search or not
+type Segment struct {
+	Text  string
+	Match bool
+}
+
+// Snippet is the passage of the text of a search result best matching the
+// search, telling whether text was cut before or after it
+type Snippet struct {
+	Segments []Segment
+	Leading  bool
+	Trailing bool
+}
+
+// Truncated tells whether the snippet leaves out part of the text
+func (s Snippet) Truncated() bool {
+	return s.Leading || s.Trailing
+}
+
+type word struct {
+	start, end int
+	stem       string
+}
+
+// words splits a text into its words, letters and digits, with their offsets
+func words(text string) []word {
+	found := []word{}
+	start := -1
+	for idx, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if inWord && start < 0 {
+			start = idx
+		} else if !inWord && start >= 0 {
+			found = append(found, word{start, idx, Stem(text[start:idx])})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		found = append(found, word{start, len(text), Stem(text[start:])})
+	}
+	return found
+}
+
+// SearchTerms returns the stems of the words of a search worth highlighting,
+// leaving out the stop words and the words excluded with a leading -
+func SearchTerms(query string) map[string]bool {
+	terms := map[string]bool{}
+	for _, w := range words(query) {
+		if w.start > 0 && query[w.start-1] == '-' && (w.start == 1 || unicode.IsSpace(rune(query[w.start-2]))) {
+			continue
+		}
+		if stopWords[strings.ToLower(query[w.start:w.end])] {
+			continue
+		}
+		terms[w.stem] = true
+	}
+	return terms
+}
+
+// HighlightResults extracts the snippet of each search result and highlights
+// the terms of the search in it and in the full text, matching them whatever
+// their case or ending
+func HighlightResults(results []SearchResult, query string) {
+	terms := SearchTerms(query)
+	for idx := range results {
+		results[idx].Snippet, results[idx].Highlighted = ExtractSnippet(results[idx].Text, terms)
+	}
+}
+
+// ExtractSnippet returns the passage of a text with the most distinct terms of
+// the search, then the most matches, and the whole text, both highlighted.
+// Without any match, the passage is the beginning of the text.
+func ExtractSnippet(text string, terms map[string]bool) (Snippet, []Segment) {
+	textWords := words(text)
+	highlighted := highlight(text, textWords, terms, 0, len(text))
+	if len(textWords) <= snippetWords {
+		return Snippet{Segments: highlighted}, highlighted
+	}
+	// the candidate passages start a few words before each match
+	start, bestDistinct, bestMatches := 0, 0, 0
+	for idx, w := range textWords {
+		if !terms[w.stem] {
+			continue
+		}
+		candidate := min(max(idx-snippetContext, 0), len(textWords)-snippetWords)
+		seen := map[string]bool{}
+		matches := 0
+		for _, w := range textWords[candidate : candidate+snippetWords] {
+			if terms[w.stem] {
+				seen[w.stem] = true
+				matches++
+			}
+		}
+		if len(seen) > bestDistinct || (len(seen) == bestDistinct && matches > bestMatches) {
+			start, bestDistinct, bestMatches = candidate, len(seen), matches
+		}
+	}
+	end := start + snippetWords
+	snippet := Snippet{
+		Segments: highlight(text, textWords, terms, textWords[start].start, textWords[end-1].end),
+		Leading:  start > 0,
+		Trailing: end < len(textWords),
+	}
+	return snippet, highlighted
+}
+
+// highlight splits the text between two offsets into segments, the words
+// matching the terms standing alone
+func highlight(text string, textWords []word, terms map[string]bool, from int, to int) []Segment {
+	segments := []Segment{}
+	last := from
+	for _, w := range textWords {
+		if w.start < from || w.end > to || !terms[w.stem] {
+			continue
+		}
+		if w.start > last {
+			segments = append(segments, Segment{Text: text[last:w.start]})
+		}
+		segments = append(segments, Segment{Text: text[w.start:w.end], Match: true})
+		last = w.end
+	}
+	if last < to {
+		segments = append(segments, Segment{Text: text[last:to]})
+	}
+	return segments
+}
+
+// Stem reduces an English word to a stem shared by its inflections, lower
+// cased, so that "divides", "dividing" and "Divided" all match "divide". It
+// strips the common plural, tense and adverb endings rather than implementing
+// a full stemmer.
+func Stem(w string) string {
+	stem := []rune(strings.ToLower(w))
+	if len(stem) <= 3 {
+		return string(stem)
+	}
+	switch {
+	case hasSuffix(stem, "sses"):
+		stem = stem[:len(stem)-2]
+	case hasSuffix(stem, "ies"):
+		stem = append(stem[:len(stem)-3], 'i')
+	case hasSuffix(stem, "s") && !hasSuffix(stem, "ss") && !hasSuffix(stem, "us") && !hasSuffix(stem, "is"):
+		stem = stem[:len(stem)-1]
+	}
+	for _, suffix := range []string{"ing", "ed"} {
+		if !hasSuffix(stem, suffix) {
+			continue
+		}
+		if base := stem[:len(stem)-len(suffix)]; len(base) >= 3 && hasVowel(base) {
+			stem = base
+			// running, stopped
+			if n := len(stem); stem[n-1] == stem[n-2] && !isVowel(stem[n-1]) && !strings.ContainsRune("lsz", stem[n-1]) {
+				stem = stem[:n-1]
+			}
+			break
+		}
+	}
+	if hasSuffix(stem, "ly") && len(stem) > 5 {
+		stem = stem[:len(stem)-2]
+	}
+	if n := len(stem); stem[n-1] == 'y' && n > 2 && !isVowel(stem[n-2]) {
+		stem[n-1] = 'i'
+	}
+	if n := len(stem); stem[n-1] == 'e' && n > 3 {
+		stem = stem[:n-1]
+	}
+	return string(stem)
+}
+
+func hasSuffix(stem []rune, suffix string) bool {
+	return len(stem) > len(suffix) && string(stem[len(stem)-len(suffix):]) == suffix
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouy", r)
+}
+
+func hasVowel(stem []rune) bool {
+	for _, r := range stem {
+		if isVowel(r) {
+			return true
+		}
+	}
+	return false
+}
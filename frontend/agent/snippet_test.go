@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	groups := [][]string{
+		{"divide", "divides", "dividing", "Divided"},
+		{"study", "studies", "studied", "studying"},
+		{"cell", "cells", "CELLS"},
+		{"run", "running", "runs"},
+		{"process", "processes", "processed"},
+	}
+	for _, group := range groups {
+		for _, w := range group[1:] {
+			if Stem(w) != Stem(group[0]) {
+				t.Errorf("Expecting %q to share the stem of %q, got %q and %q", w, group[0], Stem(w), Stem(group[0]))
+			}
+		}
+	}
+	if Stem("mitosis") == Stem("mitosi") || Stem("BRCA1") != "brca1" {
+		t.Errorf("Unexpected stems %q and %q", Stem("mitosis"), Stem("BRCA1"))
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	terms := SearchTerms(`How do "dividing cells" replicate -meiosis`)
+	for _, term := range []string{Stem("divide"), Stem("cell"), Stem("replicate")} {
+		if !terms[term] {
+			t.Errorf("Expecting %q among the terms, got %v", term, terms)
+		}
+	}
+	if len(terms) != 3 {
+		t.Errorf("Expecting stop words and excluded words to be left out, got %v", terms)
+	}
+}
+
+func matches(segments []Segment) []string {
+	found := []string{}
+	for _, segment := range segments {
+		if segment.Match {
+			found = append(found, segment.Text)
+		}
+	}
+	return found
+}
+
+func joined(segments []Segment) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		b.WriteString(segment.Text)
+	}
+	return b.String()
+}
+
+func TestExtractSnippetShortText(t *testing.T) {
+	text := "Cells <b>divide</b> by mitosis."
+	snippet, highlighted := ExtractSnippet(text, SearchTerms("cell division dividing"))
+	if snippet.Truncated() || joined(snippet.Segments) != text || joined(highlighted) != text {
+		t.Errorf("Expecting the whole short text, got %+v", snippet)
+	}
+	if found := matches(highlighted); len(found) != 2 || found[0] != "Cells" || found[1] != "divide" {
+		t.Errorf("Expecting case and ending insensitive matches, got %v", found)
+	}
+}
+
+func TestExtractSnippetLongText(t *testing.T) {
+	filler := strings.Repeat("the membrane keeps the inside apart ", 12)
+	text := filler + "BRCA1 mutations raise the risk, and BRCA1 carriers are screened early. " + filler
+	snippet, highlighted := ExtractSnippet(text, SearchTerms("brca1 mutation"))
+	if !snippet.Leading || !snippet.Trailing {
+		t.Errorf("Expecting the snippet to be cut on both sides, got %+v", snippet)
+	}
+	if found := matches(snippet.Segments); len(found) != 3 || found[1] != "mutations" {
+		t.Errorf("Expecting the snippet around the matches, got %v", found)
+	}
+	if !strings.HasPrefix(joined(snippet.Segments), "inside apart the membrane") {
+		t.Errorf("Expecting some context before the first match, got %q", joined(snippet.Segments))
+	}
+	if joined(highlighted) != text || len(matches(highlighted)) != 3 {
+		t.Errorf("Expecting the whole text highlighted, got %+v", highlighted)
+	}
+
+	snippet, _ = ExtractSnippet(text, SearchTerms("ribosome"))
+	if snippet.Leading || !snippet.Trailing || !strings.HasPrefix(joined(snippet.Segments), "the membrane") {
+		t.Errorf("Expecting the beginning of the text without matches, got %+v", snippet)
+	}
+}
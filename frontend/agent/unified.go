@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"sync"
+)
+
+const (
+	// SearchTypeSummary searches the summaries of the notes
+	SearchTypeSummary = "summary"
+	// SearchTypeFaqs searches the FAQs of the notes, answering a question
+	SearchTypeFaqs = "faqs"
+	// SearchTypeAll searches both the summaries and the FAQs
+	SearchTypeAll = "all"
+)
+
+// ProcessSearchAll runs a search on the summaries and on the FAQs
+// concurrently, merging their results. Both searches ask for every result up
+// to the end of the requested page, so that the merged results can be paged
+// as a whole: the response does not carry an offset, like those of a backend
+// that does not page.
+func ProcessSearchAll(searchInput SearchInputEvent) (*SearchResponseBody, error) {
+	searchTypes := []string{SearchTypeSummary, SearchTypeFaqs}
+	responses := make([]*SearchResponseBody, len(searchTypes))
+	errs := make([]error, len(searchTypes))
+	var wg sync.WaitGroup
+	for idx, searchType := range searchTypes {
+		search := searchInput
+		search.SearchType = searchType
+		search.TopK = searchInput.Offset + searchInput.PageSize()
+		search.Offset = 0
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[idx], errs[idx] = ProcessSearch(search)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	merged := MergeResults(responses[0].GetResults(), responses[1].GetResults())
+	return &SearchResponseBody{Status: responses[0].Status, Result: &SearchResponseResult{Value: SearchResultValue{Results: merged}}}, nil
+}
+
+// MergeResults merges lists of search results from the most to the least
+// similar, keeping the best scored of the results sharing a note and a text
+func MergeResults(lists ...[]SearchResult) []SearchResult {
+	merged := slices.Concat(lists...)
+	slices.SortStableFunc(merged, func(a, b SearchResult) int {
+		return cmp.Compare(b.Similarity, a.Similarity)
+	})
+	type key struct{ fileName, text string }
+	seen := map[key]bool{}
+	deduped := make([]SearchResult, 0, len(merged))
+	for _, result := range merged {
+		k := key{result.FileName, result.Text}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		deduped = append(deduped, result)
+	}
+	return deduped
+}
+
+// NoteResults are the search results coming from a note
+type NoteResults struct {
+	FileName string
+	Category string
+	Results  []SearchResult
+}
+
+// GroupByNote groups search results by the note they come from, in the order
+// the notes first appear. Ranked results put each note at the rank of its
+// best result.
+func GroupByNote(results []SearchResult) []NoteResults {
+	groups := []NoteResults{}
+	for _, result := range results {
+		idx := slices.IndexFunc(groups, func(g NoteResults) bool { return g.FileName == result.FileName })
+		if idx < 0 {
+			groups = append(groups, NoteResults{FileName: result.FileName, Category: result.Category})
+			idx = len(groups) - 1
+		}
+		groups[idx].Results = append(groups[idx].Results, result)
+	}
+	return groups
+}
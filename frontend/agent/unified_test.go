@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestMergeResults(t *testing.T) {
+	summaries := []SearchResult{
+		{ResultType: "summary", Text: "Cells divide", FileName: "cells.pdf", Similarity: 0.8},
+		{ResultType: "summary", Text: "Energy", FileName: "metabolism.pdf", Similarity: 0.78},
+	}
+	answers := []SearchResult{
+		{ResultType: "answer", Text: "Mitosis", FileName: "cells.pdf", Similarity: 0.9},
+		{ResultType: "answer", Text: "Cells divide", FileName: "cells.pdf", Similarity: 0.85},
+		{ResultType: "answer", Text: "Energy", FileName: "cells.pdf", Similarity: 0.76},
+	}
+	merged := MergeResults(summaries, answers)
+	expected := []struct {
+		text       string
+		similarity float64
+	}{{"Mitosis", 0.9}, {"Cells divide", 0.85}, {"Energy", 0.78}, {"Energy", 0.76}}
+	if len(merged) != len(expected) {
+		t.Fatalf("Expecting %d results, got %+v", len(expected), merged)
+	}
+	for idx, e := range expected {
+		if merged[idx].Text != e.text || merged[idx].Similarity != e.similarity {
+			t.Errorf("Expecting %s (%v) at rank %d, got %+v", e.text, e.similarity, idx, merged[idx])
+		}
+	}
+}
+
+func TestGroupByNote(t *testing.T) {
+	results := []SearchResult{
+		{Text: "Mitosis", FileName: "cells.pdf", Category: "biology"},
+		{Text: "ATP", FileName: "metabolism.pdf", Category: "biology"},
+		{Text: "Meiosis", FileName: "cells.pdf", Category: "biology"},
+	}
+	groups := GroupByNote(results)
+	if len(groups) != 2 || groups[0].FileName != "cells.pdf" || len(groups[0].Results) != 2 || groups[1].FileName != "metabolism.pdf" || len(groups[1].Results) != 1 {
+		t.Errorf("Unexpected groups %+v", groups)
+	}
+	if groups[0].Results[1].Text != "Meiosis" {
+		t.Errorf("Expecting the results of a note to keep their order, got %+v", groups[0].Results)
+	}
+}
+
+func TestProcessSearchAll(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]SearchInputEvent{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body SearchRequestBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		requests[body.StartEvent.SearchType] = body.StartEvent
+		mu.Unlock()
+		resultType := "summary"
+		if body.StartEvent.SearchType == SearchTypeFaqs {
+			resultType = "answer"
+		}
+		_ = json.NewEncoder(w).Encode(SearchResponseBody{Status: "completed", Result: &SearchResponseResult{Value: SearchResultValue{Results: []SearchResult{{ResultType: resultType, Text: resultType, FileName: "cells.pdf", Similarity: 0.8}}}}})
+	}))
+	defer server.Close()
+	t.Setenv("SEARCH_API_ENDPOINT", server.URL)
+
+	response, err := ProcessSearchAll(SearchInputEvent{SearchType: SearchTypeAll, SearchInput: "mitosis", TopK: 6, Offset: 10})
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err.Error())
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expecting a search of the summaries and one of the FAQs, got %+v", requests)
+	}
+	for searchType, request := range requests {
+		if request.TopK != 16 || request.Offset != 0 {
+			t.Errorf("Expecting the %s search to ask for the results up to the end of the page, got top_k %d and offset %d", searchType, request.TopK, request.Offset)
+		}
+	}
+	if results := response.GetResults(); len(results) != 2 || response.Result.Value.Offset != nil {
+		t.Errorf("Expecting both results merged without an offset, got %+v", response.Result.Value)
+	}
+}
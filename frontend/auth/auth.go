@@ -3,6 +3,9 @@ package auth
 import (
 	"context"
 	"errors"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -53,3 +56,13 @@ func AuthorizeGet(c *fiber.Ctx) (*db.User, error) {
 	}
 	return &user, nil
 }
+
+// IsAdmin reports whether the user is listed in the comma-separated
+// ADMIN_USERS environment variable
+func IsAdmin(username string) bool {
+	if username == "" {
+		return false
+	}
+	admins := strings.Split(os.Getenv("ADMIN_USERS"), ",")
+	return slices.ContainsFunc(admins, func(admin string) bool { return strings.TrimSpace(admin) == username })
+}
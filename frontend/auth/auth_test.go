@@ -46,3 +46,17 @@ func TestAuthorizePostFail(t *testing.T) {
 		}
 	}
 }
+
+func TestIsAdmin(t *testing.T) {
+	t.Setenv("ADMIN_USERS", "alice, bob")
+	if !IsAdmin("alice") || !IsAdmin("bob") {
+		t.Error("Expected alice and bob to be admins")
+	}
+	if IsAdmin("carol") || IsAdmin("") {
+		t.Error("Expected carol and the empty username not to be admins")
+	}
+	t.Setenv("ADMIN_USERS", "")
+	if IsAdmin("alice") {
+		t.Error("Expected no admins when ADMIN_USERS is empty")
+	}
+}
@@ -0,0 +1,130 @@
+// Package charts lays out simple bar and line charts, which the templates
+// render server-side as SVG
+package charts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	barRowHeight  = 28
+	barHeight     = 18
+	labelWidth    = 140
+	maxLabelRunes = 20
+	valueWidth    = 60
+	linePadding   = 24
+)
+
+// Point is a labelled value. Display is the formatted value shown on the chart,
+// the raw value being used when it is empty.
+type Point struct {
+	Label   string
+	Value   float64
+	Display string
+}
+
+func (p Point) display() string {
+	if p.Display != "" {
+		return p.Display
+	}
+	return strconv.FormatFloat(p.Value, 'f', -1, 64)
+}
+
+// Bar is a horizontal bar, drawn from LabelWidth on the row starting at Y.
+// ShortLabel is the label truncated to fit the space left of the bars.
+type Bar struct {
+	Label      string
+	ShortLabel string
+	Value      string
+	Y          float64
+	Width      float64
+}
+
+// BarChart is a horizontal bar chart, one row per point
+type BarChart struct {
+	Width      float64
+	Height     float64
+	LabelWidth float64
+	BarHeight  float64
+	Bars       []Bar
+}
+
+// NewBarChart scales the points to the width of the chart, the longest bar
+// spanning the space left after the labels and the values
+func NewBarChart(points []Point, width float64) BarChart {
+	chart := BarChart{Width: width, Height: float64(len(points) * barRowHeight), LabelWidth: labelWidth, BarHeight: barHeight, Bars: make([]Bar, 0, len(points))}
+	maxValue := 0.0
+	for _, point := range points {
+		maxValue = max(maxValue, point.Value)
+	}
+	span := max(width-labelWidth-valueWidth, 0)
+	for idx, point := range points {
+		bar := Bar{Label: point.Label, ShortLabel: truncate(point.Label, maxLabelRunes), Value: point.display(), Y: float64(idx * barRowHeight)}
+		if maxValue > 0 && point.Value > 0 {
+			bar.Width = point.Value / maxValue * span
+		}
+		chart.Bars = append(chart.Bars, bar)
+	}
+	return chart
+}
+
+func truncate(label string, limit int) string {
+	runes := []rune(label)
+	if len(runes) <= limit {
+		return label
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// LinePoint is a vertex of a line chart
+type LinePoint struct {
+	X     float64
+	Y     float64
+	Label string
+	Value string
+}
+
+// LineChart is a line chart with evenly spaced points, the baseline being at Baseline
+type LineChart struct {
+	Width    float64
+	Height   float64
+	Baseline float64
+	Path     string
+	Points   []LinePoint
+}
+
+// NewLineChart lays out the points from left to right, scaling their values to
+// the height of the chart
+func NewLineChart(points []Point, width float64, height float64) LineChart {
+	chart := LineChart{Width: width, Height: height, Baseline: height - linePadding, Points: make([]LinePoint, 0, len(points))}
+	maxValue := 0.0
+	for _, point := range points {
+		maxValue = max(maxValue, point.Value)
+	}
+	step := 0.0
+	if len(points) > 1 {
+		step = (width - 2*linePadding) / float64(len(points)-1)
+	}
+	path := []string{}
+	for idx, point := range points {
+		vertex := LinePoint{X: linePadding + float64(idx)*step, Y: chart.Baseline, Label: point.Label, Value: point.display()}
+		if maxValue > 0 {
+			vertex.Y = chart.Baseline - point.Value/maxValue*(height-2*linePadding)
+		}
+		command := "L"
+		if idx == 0 {
+			command = "M"
+		}
+		path = append(path, fmt.Sprintf("%s%s %s", command, Number(vertex.X), Number(vertex.Y)))
+		chart.Points = append(chart.Points, vertex)
+	}
+	chart.Path = strings.Join(path, " ")
+	return chart
+}
+
+// Number formats a coordinate for an SVG attribute
+func Number(value float64) string {
+	return strconv.FormatFloat(value, 'f', 1, 64)
+}
@@ -0,0 +1,34 @@
+package charts
+
+import "testing"
+
+func TestNewBarChart(t *testing.T) {
+	chart := NewBarChart([]Point{{Label: "Biology", Value: 4}, {Label: "History", Value: 2, Display: "2 notes"}, {Label: "Empty"}}, 400)
+	if chart.Height != 3*barRowHeight || len(chart.Bars) != 3 {
+		t.Fatalf("Unexpected chart %+v", chart)
+	}
+	span := 400.0 - labelWidth - valueWidth
+	if chart.Bars[0].Width != span || chart.Bars[1].Width != span/2 || chart.Bars[2].Width != 0 {
+		t.Errorf("Expecting the bars to be scaled to the longest one, got %+v", chart.Bars)
+	}
+	if chart.Bars[0].Value != "4" || chart.Bars[1].Value != "2 notes" {
+		t.Errorf("Unexpected bar values %+v", chart.Bars)
+	}
+	if long := NewBarChart([]Point{{Label: "Molecular and Cellular Biology"}}, 400); long.Bars[0].ShortLabel != "Molecular and Cellu…" {
+		t.Errorf("Expecting long labels to be truncated, got %q", long.Bars[0].ShortLabel)
+	}
+	if chart.Bars[1].Y != barRowHeight {
+		t.Errorf("Expecting the second bar on the second row, got %v", chart.Bars[1].Y)
+	}
+}
+
+func TestNewLineChart(t *testing.T) {
+	chart := NewLineChart([]Point{{Label: "a", Value: 0}, {Label: "b", Value: 2}, {Label: "c", Value: 1}}, 248, 148)
+	if chart.Path != "M24.0 124.0 L124.0 24.0 L224.0 74.0" {
+		t.Errorf("Unexpected path %q", chart.Path)
+	}
+	flat := NewLineChart([]Point{{Label: "a"}, {Label: "b"}}, 248, 148)
+	if flat.Points[0].Y != flat.Baseline || flat.Points[1].Y != flat.Baseline {
+		t.Errorf("Expecting a flat line on the baseline without values, got %+v", flat.Points)
+	}
+}
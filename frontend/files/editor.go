@@ -0,0 +1,33 @@
+package files
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const untitledNoteName = "Untitled note"
+
+// markdown renders notes written in the editor. Raw HTML is left out of the
+// output and dangerous link schemes are dropped, so notes are safe to display.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// RenderMarkdown converts the markdown body of a written note to HTML
+func RenderMarkdown(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// NoteFileName returns the name of the markdown file a written note is
+// uploaded as, derived from its title
+func NoteFileName(title string) string {
+	name := sanitizeFileName(title)
+	if name == "" {
+		name = untitledNoteName
+	}
+	return name + ".md"
+}
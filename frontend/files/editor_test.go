@@ -0,0 +1,37 @@
+package files
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	rendered, err := RenderMarkdown("# Photosynthesis\n\nPlants turn **light** into energy.\n\n<script>alert(1)</script>\n\n[click](javascript:alert(1))")
+	if err != nil {
+		t.Fatalf("Not expecting an error, got %s", err.Error())
+	}
+	for _, expected := range []string{"<h1>Photosynthesis</h1>", "<strong>light</strong>"} {
+		if !strings.Contains(rendered, expected) {
+			t.Errorf("Expecting the rendered note to contain %q, got %s", expected, rendered)
+		}
+	}
+	for _, unexpected := range []string{"<script>", "javascript:"} {
+		if strings.Contains(rendered, unexpected) {
+			t.Errorf("Not expecting the rendered note to contain %q, got %s", unexpected, rendered)
+		}
+	}
+}
+
+func TestNoteFileName(t *testing.T) {
+	testCases := map[string]string{
+		"Lecture 3: Mitosis":  "Lecture 3 Mitosis.md",
+		"  ../../etc/passwd ": "etc passwd.md",
+		"":                    "Untitled note.md",
+		"???":                 "Untitled note.md",
+	}
+	for title, expected := range testCases {
+		if name := NoteFileName(title); name != expected {
+			t.Errorf("Expecting %q to be named %q, got %q", title, expected, name)
+		}
+	}
+}
@@ -4,9 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type UploadedFile struct {
@@ -22,27 +29,105 @@ type UploadedFile struct {
 	ProjectID      string         `json:"project_id"`
 	ResourceInfo   map[string]any `json:"resource_info"`
 	UpdatedAt      *string        `json:"updated_at"`
+	// PageCount is not part of the LlamaCloud response: it is counted locally
+	// from the uploaded bytes when the file is a PDF.
+	PageCount *int32 `json:"-"`
+}
+
+var pdfPageRegex = regexp.MustCompile(`/Type\s*/Page[^s]`)
+
+func countPdfPages(content []byte) *int32 {
+	if !bytes.HasPrefix(content, []byte("%PDF")) {
+		return nil
+	}
+	count := int32(len(pdfPageRegex.FindAll(content, -1)))
+	if count == 0 {
+		return nil
+	}
+	return &count
+}
+
+// GetMimeType returns the MIME type of the uploaded file, derived from the
+// file type reported by LlamaCloud or, as a fallback, from the file name.
+func (f *UploadedFile) GetMimeType() pgtype.Text {
+	ext := filepath.Ext(f.Name)
+	if f.FileType != nil && *f.FileType != "" {
+		if strings.Contains(*f.FileType, "/") {
+			return pgtype.Text{String: *f.FileType, Valid: true}
+		}
+		ext = "." + strings.TrimPrefix(*f.FileType, ".")
+	}
+	mimeType := mime.TypeByExtension(ext)
+	if mimeType == "" {
+		return pgtype.Text{}
+	}
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: mediaType, Valid: true}
+}
+
+func (f *UploadedFile) GetFileSize() pgtype.Int8 {
+	if f.FileSize == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *f.FileSize, Valid: true}
 }
 
-func UploadFile(file io.Reader, fileName string) (string, error) {
+func (f *UploadedFile) GetPageCount() pgtype.Int4 {
+	if f.PageCount == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: *f.PageCount, Valid: true}
+}
+
+func (f *UploadedFile) GetUploadedAt() pgtype.Timestamp {
+	if f.CreatedAt == nil {
+		return pgtype.Timestamp{}
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, *f.CreatedAt)
+	if err != nil {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: createdAt.UTC(), Valid: true}
+}
+
+const defaultLlamaCloudBaseUrl = "https://api.cloud.llamaindex.ai"
+
+// LlamaCloudBaseUrl returns the base URL of the LlamaCloud API, which can be
+// overridden through LLAMA_CLOUD_BASE_URL
+func LlamaCloudBaseUrl() string {
+	baseUrl := os.Getenv("LLAMA_CLOUD_BASE_URL")
+	if baseUrl == "" {
+		baseUrl = defaultLlamaCloudBaseUrl
+	}
+	return strings.TrimSuffix(baseUrl, "/")
+}
+
+func UploadFile(file io.Reader, fileName string) (*UploadedFile, error) {
 	apiKey := os.Getenv("LLAMA_CLOUD_API_KEY")
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
 	var requestBody bytes.Buffer
 	writer := multipart.NewWriter(&requestBody)
 
 	fileWriter, _ := writer.CreateFormFile("upload_file", fileName)
 
-	_, _ = io.Copy(fileWriter, file)
+	_, _ = fileWriter.Write(content)
 
 	contentType := writer.FormDataContentType()
 	_ = writer.Close()
-	url := "https://api.cloud.llamaindex.ai/api/v1/files"
+	url := LlamaCloudBaseUrl() + "/api/v1/files"
 	method := "POST"
 
 	client := &http.Client{}
 	req, err := http.NewRequest(method, url, &requestBody)
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	req.Header.Add("Content-Type", contentType)
 	req.Header.Add("Accept", "application/json")
@@ -50,18 +135,19 @@ func UploadFile(file io.Reader, fileName string) (string, error) {
 
 	res, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer func() { _ = res.Body.Close() }()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	var fl UploadedFile
 	err = json.Unmarshal(body, &fl)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return fl.ID, nil
+	fl.PageCount = countPdfPages(content)
+	return &fl, nil
 }
@@ -11,8 +11,42 @@ func TestUploadFile(t *testing.T) {
 	}
 	file := "../testfiles/the-future-of-vibe-coding.pdf"
 	src, _ := os.Open(file)
-	_, err := UploadFile(src, file)
+	uploaded, err := UploadFile(src, file)
 	if err != nil {
 		t.Errorf("Expected no error, got %s", err.Error())
+	} else if uploaded.ID == "" {
+		t.Error("Expected the uploaded file to have an ID, got none")
+	}
+}
+
+func TestCountPdfPages(t *testing.T) {
+	content, err := os.ReadFile("../testfiles/the-future-of-vibe-coding.pdf")
+	if err != nil {
+		t.Fatalf("Not expecting an error while reading the test file, got %s", err.Error())
+	}
+	pages := countPdfPages(content)
+	if pages == nil || *pages == 0 {
+		t.Error("Expecting a positive page count for the test PDF, got none")
+	}
+	if countPdfPages([]byte("# not a pdf")) != nil {
+		t.Error("Expecting no page count for a non-PDF file")
+	}
+}
+
+func TestGetMimeType(t *testing.T) {
+	pdf := "pdf"
+	testCases := []struct {
+		file     UploadedFile
+		expected string
+	}{
+		{UploadedFile{Name: "notes.pdf"}, "application/pdf"},
+		{UploadedFile{Name: "notes", FileType: &pdf}, "application/pdf"},
+		{UploadedFile{Name: "notes"}, ""},
+	}
+	for _, tc := range testCases {
+		mimeType := tc.file.GetMimeType()
+		if mimeType.String != tc.expected {
+			t.Errorf("Expecting MIME type %q for %s, got %q", tc.expected, tc.file.Name, mimeType.String)
+		}
 	}
 }
@@ -0,0 +1,35 @@
+package files
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+const (
+	// MaxSearchHistory is the number of searches kept in the history of a user
+	MaxSearchHistory = 100
+	// ShownSearchHistory is the number of searches listed next to the search form
+	ShownSearchHistory = 20
+)
+
+// SearchHistoryEnabled reports whether the searches of a user are saved, which
+// is the case unless they opted out
+func SearchHistoryEnabled(ctx context.Context, queries *filesdb.Queries, username string) (bool, error) {
+	enabled, err := queries.GetSearchHistoryEnabled(ctx, username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true, nil
+	}
+	return enabled, err
+}
+
+// SaveSearch adds a search to the history of a user, dropping the oldest
+// searches beyond MaxSearchHistory
+func SaveSearch(ctx context.Context, queries *filesdb.Queries, entry filesdb.CreateSearchHistoryEntryParams) error {
+	if err := queries.CreateSearchHistoryEntry(ctx, entry); err != nil {
+		return err
+	}
+	return queries.TrimSearchHistory(ctx, filesdb.TrimSearchHistoryParams{Username: entry.Username, Keep: MaxSearchHistory})
+}
@@ -0,0 +1,365 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	defaultMaxImportSize      = 20 * 1024 * 1024
+	defaultMaxImportRedirects = 5
+	defaultImportTimeout      = 30 * time.Second
+)
+
+// ImportedDocument is a web resource converted into a file that can be uploaded
+type ImportedDocument struct {
+	FileName string
+	Content  []byte
+}
+
+// Importer fetches study material from the web. Requests are only allowed to
+// reach public addresses, so that users cannot make the server probe its own
+// network, and both the number of redirects and the response size are capped.
+type Importer struct {
+	MaxSize      int64
+	MaxRedirects int
+	Timeout      time.Duration
+	// AllowAddress decides whether a resolved IP address may be dialed
+	AllowAddress func(ip net.IP) bool
+}
+
+func NewImporter() *Importer {
+	return &Importer{
+		MaxSize:      defaultMaxImportSize,
+		MaxRedirects: defaultMaxImportRedirects,
+		Timeout:      defaultImportTimeout,
+		AllowAddress: IsPublicAddress,
+	}
+}
+
+// IsPublicAddress reports whether an IP address is routable on the public
+// internet, rejecting loopback, private, link-local and other special ranges
+func IsPublicAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		isSpecialAddress(ip))
+}
+
+// specialRanges are the ranges the net package does not consider private but
+// that do not reach the public internet either: "this network", carrier-grade
+// NAT, benchmarking, and the NAT64 prefixes translating to IPv4 addresses that
+// may be private
+var specialRanges = []*net.IPNet{
+	{IP: net.IP{0, 0, 0, 0}, Mask: net.CIDRMask(8, 32)},
+	{IP: net.IP{100, 64, 0, 0}, Mask: net.CIDRMask(10, 32)},
+	{IP: net.IP{198, 18, 0, 0}, Mask: net.CIDRMask(15, 32)},
+	{IP: net.ParseIP("64:ff9b::"), Mask: net.CIDRMask(96, 128)},
+	{IP: net.ParseIP("64:ff9b:1::"), Mask: net.CIDRMask(48, 128)},
+}
+
+func isSpecialAddress(ip net.IP) bool {
+	for _, ipNet := range specialRanges {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkImportUrl(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("only http and https URLs can be imported")
+	}
+	if u.Hostname() == "" {
+		return errors.New("the URL has no host")
+	}
+	return nil
+}
+
+func (i *Importer) client() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: i.Timeout,
+		// The address is checked once resolved, right before connecting, so
+		// that DNS answers cannot point the request to an internal host
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !i.AllowAddress(ip) {
+				return fmt.Errorf("the address %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: i.Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: i.Timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > i.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", i.MaxRedirects)
+			}
+			return checkImportUrl(req.URL)
+		},
+	}
+}
+
+// Fetch downloads a web resource and converts it into a document: HTML pages
+// become markdown, while PDFs and plain text are kept as they are
+func (i *Importer) Fetch(ctx context.Context, rawUrl string) (*ImportedDocument, error) {
+	u, err := url.Parse(strings.TrimSpace(rawUrl))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkImportUrl(u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html, application/pdf, text/plain;q=0.9, */*;q=0.1")
+	res, err := i.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching the URL failed with status %d", res.StatusCode)
+	}
+	if res.ContentLength > i.MaxSize {
+		return nil, fmt.Errorf("the resource is larger than %s", formatSize(i.MaxSize))
+	}
+	content, err := io.ReadAll(io.LimitReader(res.Body, i.MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > i.MaxSize {
+		return nil, fmt.Errorf("the resource is larger than %s", formatSize(i.MaxSize))
+	}
+
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = http.DetectContentType(content)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+	baseName := fileNameFromUrl(res.Request.URL)
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		title, markdown, err := HtmlToMarkdown(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(markdown) == "" {
+			return nil, errors.New("the page has no readable text")
+		}
+		if name := sanitizeFileName(title); name != "" {
+			baseName = name
+		}
+		return &ImportedDocument{FileName: baseName + ".md", Content: []byte(markdown)}, nil
+	case mediaType == "application/pdf":
+		return &ImportedDocument{FileName: baseName + ".pdf", Content: content}, nil
+	case mediaType == "text/markdown":
+		return &ImportedDocument{FileName: baseName + ".md", Content: content}, nil
+	case strings.HasPrefix(mediaType, "text/"):
+		return &ImportedDocument{FileName: baseName + ".txt", Content: content}, nil
+	}
+	return nil, fmt.Errorf("resources of type %s cannot be imported", mediaType)
+}
+
+func formatSize(size int64) string {
+	return fmt.Sprintf("%d MB", size/(1024*1024))
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^\p{L}\p{N} ._-]+`)
+
+func sanitizeFileName(name string) string {
+	name = unsafeFileNameChars.ReplaceAllString(name, " ")
+	name = strings.Join(strings.Fields(name), " ")
+	name = strings.Trim(name, ". ")
+	if len([]rune(name)) > 80 {
+		name = strings.TrimSpace(string([]rune(name)[:80]))
+	}
+	return name
+}
+
+func fileNameFromUrl(u *url.URL) string {
+	base := strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path))
+	name := sanitizeFileName(base)
+	if name == "" || name == "/" {
+		name = sanitizeFileName(u.Hostname())
+	}
+	return name
+}
+
+var skippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "svg": true,
+	"nav": true, "footer": true, "aside": true, "form": true,
+	"iframe": true, "button": true, "select": true, "head": true,
+}
+
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"ul": true, "ol": true, "table": true, "tr": true, "blockquote": true,
+	"pre": true, "figure": true, "figcaption": true, "dl": true, "dt": true, "dd": true,
+}
+
+var spacesRegex = regexp.MustCompile(`[ \t\r\n]+`)
+
+// HtmlToMarkdown extracts the title and the readable content of an HTML page
+// as markdown, keeping headings, paragraphs, lists, links and emphasis and
+// leaving out scripts, styles and page chrome such as navigation bars
+func HtmlToMarkdown(r io.Reader) (string, string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", err
+	}
+	var out strings.Builder
+	var walk func(n *html.Node, pre bool)
+	walkChildren := func(n *html.Node, pre bool) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, pre)
+		}
+	}
+	walk = func(n *html.Node, pre bool) {
+		switch n.Type {
+		case html.TextNode:
+			if pre {
+				out.WriteString(n.Data)
+			} else {
+				out.WriteString(spacesRegex.ReplaceAllString(n.Data, " "))
+			}
+			return
+		case html.ElementNode:
+		default:
+			walkChildren(n, pre)
+			return
+		}
+		if skippedElements[n.Data] {
+			return
+		}
+		switch n.Data {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			out.WriteString("\n\n" + strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+			walkChildren(n, pre)
+			out.WriteString("\n\n")
+		case "br":
+			out.WriteString("\n")
+		case "hr":
+			out.WriteString("\n\n---\n\n")
+		case "li":
+			marker := "- "
+			if n.Parent != nil && n.Parent.Data == "ol" {
+				index := 1
+				for sibling := n.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+					if sibling.Type == html.ElementNode && sibling.Data == "li" {
+						index++
+					}
+				}
+				marker = fmt.Sprintf("%d. ", index)
+			}
+			out.WriteString("\n" + marker)
+			walkChildren(n, pre)
+		case "strong", "b":
+			out.WriteString("**")
+			walkChildren(n, pre)
+			out.WriteString("**")
+		case "em", "i":
+			out.WriteString("_")
+			walkChildren(n, pre)
+			out.WriteString("_")
+		case "code":
+			if pre {
+				walkChildren(n, pre)
+			} else {
+				out.WriteString("`")
+				walkChildren(n, pre)
+				out.WriteString("`")
+			}
+		case "pre":
+			out.WriteString("\n\n```\n")
+			walkChildren(n, true)
+			out.WriteString("\n```\n\n")
+		case "a":
+			href := ""
+			for _, attr := range n.Attr {
+				if attr.Key == "href" {
+					href = attr.Val
+				}
+			}
+			if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
+				out.WriteString("[")
+				walkChildren(n, pre)
+				out.WriteString("](" + href + ")")
+			} else {
+				walkChildren(n, pre)
+			}
+		case "td", "th":
+			walkChildren(n, pre)
+			out.WriteString(" | ")
+		default:
+			if blockElements[n.Data] {
+				out.WriteString("\n\n")
+				walkChildren(n, pre)
+				out.WriteString("\n\n")
+			} else {
+				walkChildren(n, pre)
+			}
+		}
+	}
+	walk(doc, false)
+	title := findTitle(doc)
+
+	// lines are trimmed and blank lines collapsed, except in code blocks
+	// where the indentation matters
+	lines := []string{}
+	fenced := false
+	for _, line := range strings.Split(out.String(), "\n") {
+		if fenced && line != "```" {
+			lines = append(lines, line)
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if line == "```" {
+			fenced = !fenced
+		}
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return title, strings.TrimSpace(strings.Join(lines, "\n")) + "\n", nil
+}
+
+func findTitle(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "title" {
+		if n.FirstChild != nil {
+			return strings.TrimSpace(spacesRegex.ReplaceAllString(n.FirstChild.Data, " "))
+		}
+		return ""
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if title := findTitle(child); title != "" {
+			return title
+		}
+	}
+	return ""
+}
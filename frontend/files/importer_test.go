@@ -0,0 +1,194 @@
+package files
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const fixturePage = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Cell Biology: The Basics</title>
+	<style>body { color: red; }</style>
+	<script>alert("not study material")</script>
+</head>
+<body>
+	<nav><a href="/">Home</a></nav>
+	<article>
+		<h1>The cell</h1>
+		<p>The <strong>cell</strong> is the basic unit of   life.</p>
+		<ul><li>Nucleus</li><li>Mitochondria</li></ul>
+		<ol><li>First</li><li>Second</li></ol>
+		<p>Read more on <a href="https://en.wikipedia.org/wiki/Cell">Wikipedia</a> &amp; elsewhere.</p>
+	</article>
+	<footer>Copyright</footer>
+</body>
+</html>`
+
+func newFixtureServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(fixturePage))
+	})
+	mux.HandleFunc("/papers/mitosis.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write([]byte("%PDF-1.4\n/Type /Page\n"))
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Repeat("a", 2048)))
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("\x89PNG"))
+	})
+	mux.HandleFunc("/notes/untitled.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(strings.Replace(fixturePage, "Cell Biology: The Basics", "...", 1)))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/article", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	return httptest.NewServer(mux)
+}
+
+// newLocalImporter allows loopback addresses, so that the fixture server can be reached
+func newLocalImporter() *Importer {
+	importer := NewImporter()
+	importer.MaxSize = 1024
+	importer.AllowAddress = func(ip net.IP) bool { return ip.IsLoopback() }
+	return importer
+}
+
+func TestIsPublicAddress(t *testing.T) {
+	testCases := []struct {
+		ip       string
+		expected bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"10.0.0.5", false},
+		{"172.16.3.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"198.18.0.1", false},
+		{"198.19.255.254", false},
+		{"198.20.0.1", true},
+		{"64:ff9b::a00:1", false},
+		{"64:ff9b::7f00:1", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+	}
+	for _, tc := range testCases {
+		if result := IsPublicAddress(net.ParseIP(tc.ip)); result != tc.expected {
+			t.Errorf("Expecting IsPublicAddress(%s) to be %v, got %v", tc.ip, tc.expected, result)
+		}
+	}
+}
+
+func TestHtmlToMarkdown(t *testing.T) {
+	title, markdown, err := HtmlToMarkdown(strings.NewReader(fixturePage))
+	if err != nil {
+		t.Fatalf("Not expecting an error, got %s", err.Error())
+	}
+	if title != "Cell Biology: The Basics" {
+		t.Errorf("Expecting the page title to be extracted, got %q", title)
+	}
+	for _, expected := range []string{
+		"# The cell",
+		"The **cell** is the basic unit of life.",
+		"- Nucleus\n- Mitochondria",
+		"1. First\n2. Second",
+		"[Wikipedia](https://en.wikipedia.org/wiki/Cell) & elsewhere.",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expecting the markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+	for _, unexpected := range []string{"alert", "color: red", "Home", "Copyright"} {
+		if strings.Contains(markdown, unexpected) {
+			t.Errorf("Not expecting the markdown to contain %q, got:\n%s", unexpected, markdown)
+		}
+	}
+}
+
+func TestHtmlToMarkdownKeepsCodeIndentation(t *testing.T) {
+	page := "<html><body><p>  Before   the code  </p><pre><code>def area(r):\n    if r &lt; 0:\n        raise ValueError\n\n\n\n    return 3.14 * r * r</code></pre><p>After</p></body></html>"
+	_, markdown, err := HtmlToMarkdown(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Not expecting an error, got %s", err.Error())
+	}
+	expected := "Before the code\n\n```\ndef area(r):\n    if r < 0:\n        raise ValueError\n\n\n\n    return 3.14 * r * r\n```\n\nAfter\n"
+	if markdown != expected {
+		t.Errorf("Expecting the code block to be kept as is, got:\n%q", markdown)
+	}
+}
+
+func TestImporterFetch(t *testing.T) {
+	server := newFixtureServer()
+	defer server.Close()
+	importer := newLocalImporter()
+
+	doc, err := importer.Fetch(context.Background(), server.URL+"/moved")
+	if err != nil {
+		t.Fatalf("Not expecting an error when importing a page, got %s", err.Error())
+	}
+	if doc.FileName != "Cell Biology The Basics.md" {
+		t.Errorf("Expecting the file to be named after the page title, got %q", doc.FileName)
+	}
+	if !strings.Contains(string(doc.Content), "# The cell") {
+		t.Errorf("Expecting the page to be converted to markdown, got:\n%s", doc.Content)
+	}
+
+	doc, err = importer.Fetch(context.Background(), server.URL+"/notes/untitled.html")
+	if err != nil {
+		t.Fatalf("Not expecting an error when importing a page without a usable title, got %s", err.Error())
+	}
+	if doc.FileName != "untitled.md" {
+		t.Errorf("Expecting the file to be named after the URL when the title has no usable characters, got %q", doc.FileName)
+	}
+
+	doc, err = importer.Fetch(context.Background(), server.URL+"/papers/mitosis.pdf")
+	if err != nil {
+		t.Fatalf("Not expecting an error when importing a PDF, got %s", err.Error())
+	}
+	if doc.FileName != "mitosis.pdf" || countPdfPages(doc.Content) == nil {
+		t.Errorf("Expecting the PDF to be kept as it is, got %q", doc.FileName)
+	}
+
+	failures := map[string]string{
+		server.URL + "/large":     "larger than",
+		server.URL + "/image.png": "cannot be imported",
+		server.URL + "/loop":      "redirects",
+		server.URL + "/missing":   "status 404",
+		"file:///etc/passwd":      "only http and https",
+	}
+	for url, expected := range failures {
+		_, err := importer.Fetch(context.Background(), url)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expecting an error containing %q for %s, got %v", expected, url, err)
+		}
+	}
+}
+
+func TestImporterBlocksPrivateAddresses(t *testing.T) {
+	server := newFixtureServer()
+	defer server.Close()
+	_, err := NewImporter().Fetch(context.Background(), server.URL+"/article")
+	if err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("Expecting requests to loopback addresses to be blocked, got %v", err)
+	}
+}
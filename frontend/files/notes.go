@@ -0,0 +1,130 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+// StudyNotes is the summary and the list of FAQs extracted from a note
+type StudyNotes struct {
+	Summary string    `json:"summary"`
+	Faqs    []NoteFaq `json:"faqs"`
+}
+
+type NoteFaq struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// LoadStudyNotes reads the stored summary and FAQs of a file
+func LoadStudyNotes(ctx context.Context, queries *filesdb.Queries, fileId int32) (StudyNotes, error) {
+	notes := StudyNotes{Faqs: []NoteFaq{}}
+	summary, err := queries.GetFileSummary(ctx, fileId)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return notes, err
+	}
+	notes.Summary = summary.Summary
+	faqs, err := queries.GetFileFaqs(ctx, fileId)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return notes, err
+	}
+	for _, faq := range faqs {
+		notes.Faqs = append(notes.Faqs, NoteFaq{Question: faq.Question, Answer: faq.Answer})
+	}
+	return notes, nil
+}
+
+func replaceStudyNotes(ctx context.Context, queries *filesdb.Queries, fileId int32, notes StudyNotes) error {
+	err := queries.UpsertFileSummary(ctx, filesdb.UpsertFileSummaryParams{FileID: fileId, Summary: notes.Summary})
+	if err != nil {
+		return err
+	}
+	err = queries.DeleteFileFaqs(ctx, fileId)
+	if err != nil {
+		return err
+	}
+	for _, faq := range notes.Faqs {
+		_, err = queries.CreateFileFaq(ctx, filesdb.CreateFileFaqParams{FileID: fileId, Question: faq.Question, Answer: faq.Answer})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveStudyNotes replaces the stored summary and FAQs of a file in a single transaction
+func SaveStudyNotes(ctx context.Context, db *pgx.Conn, fileId int32, notes StudyNotes) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	err = replaceStudyNotes(ctx, filesdb.New(db).WithTx(tx), fileId, notes)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ErrNotReindexed is returned by EditStudyNotes when the edit was saved but the
+// search backend could not be updated
+var ErrNotReindexed = errors.New("the edit was saved, but the note could not be re-indexed: save it again or revert it")
+
+// EditStudyNotes records the current notes of a file in its edit history, then
+// applies the edit and hands the resulting notes to reindex once committed. If
+// reindexing fails the edit stays saved, along with its history entry, and
+// ErrNotReindexed is returned so the user can retry or revert it.
+func EditStudyNotes(ctx context.Context, db *pgx.Conn, fileId int32, description string, edit func(*filesdb.Queries) error, reindex func(StudyNotes) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	queries := filesdb.New(db).WithTx(tx)
+	before, err := LoadStudyNotes(ctx, queries, fileId)
+	if err != nil {
+		return err
+	}
+	snapshot, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	err = queries.CreateNoteEdit(ctx, filesdb.CreateNoteEditParams{FileID: fileId, Description: description, Snapshot: snapshot})
+	if err != nil {
+		return err
+	}
+	err = edit(queries)
+	if err != nil {
+		return err
+	}
+	after, err := LoadStudyNotes(ctx, queries, fileId)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit(ctx)
+	if err != nil {
+		return err
+	}
+	err = reindex(after)
+	if err != nil {
+		return fmt.Errorf("%w (%w)", ErrNotReindexed, err)
+	}
+	return nil
+}
+
+// RevertStudyNotes returns an edit function restoring the notes saved in a history entry
+func RevertStudyNotes(ctx context.Context, fileId int32, entry filesdb.FileNoteEdit) (func(*filesdb.Queries) error, error) {
+	var notes StudyNotes
+	err := json.Unmarshal(entry.Snapshot, &notes)
+	if err != nil {
+		return nil, err
+	}
+	return func(queries *filesdb.Queries) error {
+		return replaceStudyNotes(ctx, queries, fileId, notes)
+	}, nil
+}
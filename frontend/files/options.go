@@ -0,0 +1,73 @@
+package files
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+var SortFields = []string{"date", "size", "name"}
+
+// ListOptions holds the sorting and filtering options for the notes page
+type ListOptions struct {
+	SortBy   string
+	SortDesc bool
+	Category string
+	// Subcategories holds the labels of the subcategories of Category, whose
+	// notes are listed along with the ones of the category
+	Subcategories []string
+	MimeType      string
+	Tag           string
+	// Course restricts the list to the notes of a course, 0 meaning all courses
+	Course int32
+	// ExcludedCourses hides the notes of these courses, usually the archived
+	// ones, unless one of them is explicitly selected
+	ExcludedCourses []int32
+}
+
+// ParseListOptions validates the raw query values, falling back to the
+// newest-first ordering when the sort field is unknown.
+func ParseListOptions(sortBy, order, category, mimeType, tag, course string) ListOptions {
+	if !slices.Contains(SortFields, sortBy) {
+		sortBy = "date"
+		if order == "" {
+			order = "desc"
+		}
+	}
+	courseId, err := strconv.ParseInt(course, 10, 32)
+	if err != nil || courseId < 0 {
+		courseId = 0
+	}
+	return ListOptions{
+		SortBy:   sortBy,
+		SortDesc: order == "desc",
+		Category: category,
+		MimeType: mimeType,
+		Tag:      NormalizeTag(tag),
+		Course:   int32(courseId),
+	}
+}
+
+func (o ListOptions) Params(username string) filesdb.ListFilesParams {
+	params := filesdb.ListFilesParams{Username: username, SortBy: o.SortBy, SortDesc: o.SortDesc, Subcategories: []string{}, ExcludedCourses: []int32{}}
+	if o.Category != "" {
+		params.FileCategory = pgtype.Text{String: o.Category, Valid: true}
+		if o.Subcategories != nil {
+			params.Subcategories = o.Subcategories
+		}
+	}
+	if o.MimeType != "" {
+		params.MimeType = pgtype.Text{String: o.MimeType, Valid: true}
+	}
+	if o.Tag != "" {
+		params.Tag = pgtype.Text{String: o.Tag, Valid: true}
+	}
+	if o.Course != 0 {
+		params.CourseID = pgtype.Int4{Int32: o.Course, Valid: true}
+	} else if o.ExcludedCourses != nil {
+		params.ExcludedCourses = o.ExcludedCourses
+	}
+	return params
+}
@@ -0,0 +1,87 @@
+package files
+
+import "testing"
+
+func TestParseListOptions(t *testing.T) {
+	testCases := []struct {
+		sortBy       string
+		order        string
+		expectedSort string
+		expectedDesc bool
+	}{
+		{"", "", "date", true},
+		{"unknown", "", "date", true},
+		{"unknown", "asc", "date", false},
+		{"size", "", "size", false},
+		{"size", "desc", "size", true},
+		{"name", "asc", "name", false},
+	}
+	for _, tc := range testCases {
+		opts := ParseListOptions(tc.sortBy, tc.order, "", "", "", "")
+		if opts.SortBy != tc.expectedSort {
+			t.Errorf("Expecting sort field %s for input %q, got %s", tc.expectedSort, tc.sortBy, opts.SortBy)
+		}
+		if opts.SortDesc != tc.expectedDesc {
+			t.Errorf("Expecting descending order to be %v for input (%q, %q), got %v", tc.expectedDesc, tc.sortBy, tc.order, opts.SortDesc)
+		}
+	}
+}
+
+func TestListOptionsParams(t *testing.T) {
+	params := ParseListOptions("name", "asc", "biology", "", "Exam 1", "").Params("testuser")
+	if params.Username != "testuser" {
+		t.Errorf("Expecting username testuser, got %s", params.Username)
+	}
+	if !params.FileCategory.Valid || params.FileCategory.String != "biology" {
+		t.Errorf("Expecting a valid category filter set to biology, got %v", params.FileCategory)
+	}
+	if params.MimeType.Valid {
+		t.Errorf("Expecting no MIME type filter, got %s", params.MimeType.String)
+	}
+	if !params.Tag.Valid || params.Tag.String != "exam-1" {
+		t.Errorf("Expecting a normalized tag filter set to exam-1, got %v", params.Tag)
+	}
+}
+
+func TestListOptionsCourse(t *testing.T) {
+	opts := ParseListOptions("", "", "", "", "", "")
+	opts.ExcludedCourses = []int32{3}
+	params := opts.Params("testuser")
+	if params.CourseID.Valid {
+		t.Errorf("Expecting no course filter, got %d", params.CourseID.Int32)
+	}
+	if len(params.ExcludedCourses) != 1 || params.ExcludedCourses[0] != 3 {
+		t.Errorf("Expecting course 3 to be excluded, got %v", params.ExcludedCourses)
+	}
+
+	opts = ParseListOptions("", "", "", "", "", "3")
+	opts.ExcludedCourses = []int32{3}
+	params = opts.Params("testuser")
+	if !params.CourseID.Valid || params.CourseID.Int32 != 3 {
+		t.Errorf("Expecting a course filter set to 3, got %v", params.CourseID)
+	}
+	if len(params.ExcludedCourses) != 0 {
+		t.Errorf("Expecting no excluded courses when a course is selected, got %v", params.ExcludedCourses)
+	}
+
+	if opts := ParseListOptions("", "", "", "", "", "not-a-number"); opts.Course != 0 {
+		t.Errorf("Expecting an invalid course to be ignored, got %d", opts.Course)
+	}
+	if params := ParseListOptions("", "", "", "", "", "").Params("testuser"); params.ExcludedCourses == nil {
+		t.Error("Expecting the excluded courses to never be nil, since NULL would filter out every note")
+	}
+}
+
+func TestListOptionsSubcategories(t *testing.T) {
+	opts := ParseListOptions("", "", "biology", "", "", "")
+	opts.Subcategories = []string{"biology", "genetics"}
+	if params := opts.Params("testuser"); len(params.Subcategories) != 2 || params.Subcategories[1] != "genetics" {
+		t.Errorf("Expecting the subcategories to be included, got %v", params.Subcategories)
+	}
+
+	opts = ParseListOptions("", "", "", "", "", "")
+	opts.Subcategories = []string{"genetics"}
+	if params := opts.Params("testuser"); params.Subcategories == nil || len(params.Subcategories) != 0 {
+		t.Errorf("Expecting no subcategories without a category filter, got %v", params.Subcategories)
+	}
+}
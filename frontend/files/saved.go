@@ -0,0 +1,28 @@
+package files
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+const (
+	// MaxSavedSearches is the number of searches a user can save, each of them
+	// being run again whenever a note is uploaded
+	MaxSavedSearches = 20
+	// ShownSearchAlerts is the number of notifications listed on the notifications page
+	ShownSearchAlerts = 50
+)
+
+// PinSearch saves a search, to alert the user of the new notes matching it
+func PinSearch(ctx context.Context, queries *filesdb.Queries, search filesdb.CreateSavedSearchParams) error {
+	count, err := queries.CountSavedSearches(ctx, search.Username)
+	if err != nil {
+		return err
+	}
+	if count >= MaxSavedSearches {
+		return fmt.Errorf("you can save up to %d searches, delete one to save another", MaxSavedSearches)
+	}
+	return queries.CreateSavedSearch(ctx, search)
+}
@@ -4,4 +4,162 @@ CREATE TABLE IF NOT EXISTS files (
     username TEXT NOT NULL,
     file_name TEXT NOT NULL,
     file_category TEXT DEFAULT NULL
-);
\ No newline at end of file
+);
+
+-- Upload metadata
+ALTER TABLE files ADD COLUMN IF NOT EXISTS uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS file_size BIGINT DEFAULT NULL;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS page_count INTEGER DEFAULT NULL;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS mime_type TEXT DEFAULT NULL;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS llama_cloud_file_id TEXT DEFAULT NULL;
+
+-- Trash bin
+ALTER TABLE files ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP DEFAULT NULL;
+
+-- Markdown body of the notes written in the editor
+ALTER TABLE files ADD COLUMN IF NOT EXISTS content TEXT DEFAULT NULL;
+
+-- Versions of a note share the id of its first version
+ALTER TABLE files ADD COLUMN IF NOT EXISTS version_group_id INTEGER DEFAULT NULL;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE files ADD COLUMN IF NOT EXISTS superseded_at TIMESTAMP DEFAULT NULL;
+
+-- Extracted study notes
+CREATE TABLE IF NOT EXISTS file_summaries (
+    id SERIAL PRIMARY KEY,
+    file_id INTEGER NOT NULL UNIQUE REFERENCES files(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS file_faqs (
+    id SERIAL PRIMARY KEY,
+    file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    question TEXT NOT NULL,
+    answer TEXT NOT NULL
+);
+
+-- Edit history of the extracted study notes
+CREATE TABLE IF NOT EXISTS file_note_edits (
+    id SERIAL PRIMARY KEY,
+    file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    description TEXT NOT NULL,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Deleted notes whose search index or LlamaCloud file could not be cleaned up yet
+CREATE TABLE IF NOT EXISTS pending_cleanups (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    llama_cloud_file_id TEXT DEFAULT NULL,
+    attempts INTEGER NOT NULL DEFAULT 1,
+    last_error TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Autosaved drafts of the notes being written in the editor
+CREATE TABLE IF NOT EXISTS note_drafts (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tags shared by the notes of a user
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    name TEXT NOT NULL,
+    UNIQUE (username, name)
+);
+
+CREATE TABLE IF NOT EXISTS file_tags (
+    file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (file_id, tag_id)
+);
+
+-- Courses
+ALTER TABLE files ADD COLUMN IF NOT EXISTS course_id INTEGER DEFAULT NULL;
+
+-- Categories the results of each search came from, behind the category statistics
+CREATE TABLE IF NOT EXISTS search_hits (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    category TEXT NOT NULL,
+    hits INTEGER NOT NULL,
+    best_similarity DOUBLE PRECISION NOT NULL,
+    searched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS search_hits_username_idx ON search_hits (username, searched_at);
+
+-- Searches made by a user, shown in the history of the review page
+CREATE TABLE IF NOT EXISTS search_history (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    search_type TEXT NOT NULL,
+    search_input TEXT NOT NULL,
+    file_name TEXT DEFAULT NULL,
+    category TEXT DEFAULT NULL,
+    course_id INTEGER DEFAULT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    result_count INTEGER NOT NULL,
+    searched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS search_history_username_idx ON search_history (username, searched_at);
+
+-- Search preferences, users opting out of the search history
+CREATE TABLE IF NOT EXISTS search_settings (
+    username TEXT PRIMARY KEY,
+    history_enabled BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- Searches pinned by a user, run again on each new note to alert them of new matches
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    search_type TEXT NOT NULL,
+    search_input TEXT NOT NULL,
+    file_name TEXT DEFAULT NULL,
+    category TEXT DEFAULT NULL,
+    course_id INTEGER DEFAULT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS saved_searches_username_idx ON saved_searches (username);
+
+-- Notes newly matching a saved search, listed in the notifications of a user
+CREATE TABLE IF NOT EXISTS search_alerts (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    saved_search_id INTEGER NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    hits INTEGER NOT NULL,
+    best_similarity DOUBLE PRECISION NOT NULL,
+    excerpt TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (saved_search_id, file_id)
+);
+
+CREATE INDEX IF NOT EXISTS search_alerts_username_idx ON search_alerts (username, created_at);
+
+-- Filters on several notes and categories, and exclusion filters, of the saved and past searches
+ALTER TABLE search_history ADD COLUMN IF NOT EXISTS file_names TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE search_history ADD COLUMN IF NOT EXISTS categories TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE search_history ADD COLUMN IF NOT EXISTS excluded_file_names TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE search_history ADD COLUMN IF NOT EXISTS excluded_categories TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS file_names TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS categories TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS excluded_file_names TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS excluded_categories TEXT[] NOT NULL DEFAULT '{}';
+
+-- Full-text indexes of the study notes, behind the keyword search
+CREATE INDEX IF NOT EXISTS file_summaries_search_idx ON file_summaries USING GIN (to_tsvector('english', summary));
+CREATE INDEX IF NOT EXISTS file_faqs_search_idx ON file_faqs USING GIN (to_tsvector('english', question || ' ' || answer));
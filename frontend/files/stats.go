@@ -0,0 +1,108 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+const (
+	// StatsWindow is how far back searches are counted in the statistics
+	StatsWindow = 30 * 24 * time.Hour
+	// OverviewWeeks is the number of weeks of uploads charted in the library overview
+	OverviewWeeks = 12
+)
+
+// CategoryStats aggregates the notes of a category and the searches whose
+// results came from it. AvgSimilarity is the average best match of those
+// searches, telling how well the notes answer the questions asked while
+// reviewing.
+type CategoryStats struct {
+	Notes         int64
+	LastUpload    pgtype.Timestamp
+	Faqs          int64
+	Searches      int64
+	Hits          int64
+	AvgSimilarity float64
+}
+
+// WeekCount is the number of notes uploaded during the week starting at Week
+type WeekCount struct {
+	Week  time.Time
+	Count int64
+}
+
+// MergeCategoryStats combines the per-category aggregates into statistics keyed by label
+func MergeCategoryStats(notes []filesdb.GetCategoryNoteStatsRow, faqs []filesdb.GetCategoryFaqCountsRow, searches []filesdb.GetCategorySearchStatsRow) map[string]CategoryStats {
+	stats := map[string]CategoryStats{}
+	for _, row := range notes {
+		s := stats[row.FileCategory.String]
+		s.Notes = row.NoteCount
+		s.LastUpload = row.LastUpload
+		stats[row.FileCategory.String] = s
+	}
+	for _, row := range faqs {
+		s := stats[row.FileCategory.String]
+		s.Faqs = row.FaqCount
+		stats[row.FileCategory.String] = s
+	}
+	for _, row := range searches {
+		s := stats[row.Category]
+		s.Searches = row.SearchCount
+		s.Hits = row.HitCount
+		s.AvgSimilarity = row.AvgSimilarity
+		stats[row.Category] = s
+	}
+	return stats
+}
+
+// LoadCategoryStats reads the statistics of the categories of a user, counting
+// the searches made since the given time
+func LoadCategoryStats(ctx context.Context, queries *filesdb.Queries, username string, since time.Time) (map[string]CategoryStats, error) {
+	notes, err := queries.GetCategoryNoteStats(ctx, username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	faqs, err := queries.GetCategoryFaqCounts(ctx, username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	searches, err := queries.GetCategorySearchStats(ctx, filesdb.GetCategorySearchStatsParams{Username: username, Since: pgtype.Timestamp{Time: since, Valid: true}})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return MergeCategoryStats(notes, faqs, searches), nil
+}
+
+// startOfWeek returns the Monday starting the week of t, like date_trunc('week') in Postgres
+func startOfWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// WeeklySeries lays out the weekly upload counts over the given number of weeks
+// ending with the current one, filling the weeks without uploads with zero
+func WeeklySeries(rows []filesdb.GetWeeklyUploadsRow, now time.Time, weeks int) []WeekCount {
+	counts := map[time.Time]int64{}
+	for _, row := range rows {
+		if row.Week.Valid {
+			counts[startOfWeek(row.Week.Time)] += row.NoteCount
+		}
+	}
+	first := WeeklySeriesStart(now, weeks)
+	series := make([]WeekCount, 0, weeks)
+	for idx := range weeks {
+		week := first.AddDate(0, 0, 7*idx)
+		series = append(series, WeekCount{Week: week, Count: counts[week]})
+	}
+	return series
+}
+
+// WeeklySeriesStart returns when the first week of a series of the given length begins
+func WeeklySeriesStart(now time.Time, weeks int) time.Time {
+	return startOfWeek(now).AddDate(0, 0, -7*(weeks-1))
+}
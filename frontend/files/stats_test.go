@@ -0,0 +1,45 @@
+package files
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+func TestMergeCategoryStats(t *testing.T) {
+	uploaded := pgtype.Timestamp{Time: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), Valid: true}
+	stats := MergeCategoryStats(
+		[]filesdb.GetCategoryNoteStatsRow{{FileCategory: pgtype.Text{String: "biology", Valid: true}, NoteCount: 3, LastUpload: uploaded}},
+		[]filesdb.GetCategoryFaqCountsRow{{FileCategory: pgtype.Text{String: "biology", Valid: true}, FaqCount: 12}},
+		[]filesdb.GetCategorySearchStatsRow{{Category: "history", SearchCount: 2, HitCount: 5, AvgSimilarity: 0.8}},
+	)
+	biology := stats["biology"]
+	if biology.Notes != 3 || biology.Faqs != 12 || !biology.LastUpload.Time.Equal(uploaded.Time) || biology.Searches != 0 {
+		t.Errorf("Unexpected biology statistics %+v", biology)
+	}
+	history := stats["history"]
+	if history.Notes != 0 || history.Searches != 2 || history.Hits != 5 || history.AvgSimilarity != 0.8 {
+		t.Errorf("Unexpected history statistics %+v", history)
+	}
+}
+
+func TestWeeklySeries(t *testing.T) {
+	// Thursday, the current week starts on Monday, March 16
+	now := time.Date(2026, 3, 19, 15, 0, 0, 0, time.UTC)
+	rows := []filesdb.GetWeeklyUploadsRow{
+		{Week: pgtype.Timestamp{Time: time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), Valid: true}, NoteCount: 2},
+		{Week: pgtype.Timestamp{Time: time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC), Valid: true}, NoteCount: 4},
+	}
+	series := WeeklySeries(rows, now, 3)
+	if len(series) != 3 {
+		t.Fatalf("Expecting 3 weeks, got %d", len(series))
+	}
+	if !series[0].Week.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) || series[0].Count != 0 {
+		t.Errorf("Expecting an empty first week starting on March 2, got %+v", series[0])
+	}
+	if series[1].Count != 2 || series[2].Count != 4 {
+		t.Errorf("Unexpected weekly counts %+v", series)
+	}
+}
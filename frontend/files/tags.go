@@ -0,0 +1,85 @@
+package files
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+const maxTagLength = 32
+
+var tagSeparatorsRegex = regexp.MustCompile(`[\s_]+`)
+var invalidTagCharsRegex = regexp.MustCompile(`[^\p{L}\p{N}-]+`)
+var repeatedDashesRegex = regexp.MustCompile(`-{2,}`)
+
+// NormalizeTag turns a user-provided tag into its stored form: lowercase
+// words joined by dashes, such as "exam-1" or "to-review"
+func NormalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	tag = tagSeparatorsRegex.ReplaceAllString(tag, "-")
+	tag = invalidTagCharsRegex.ReplaceAllString(tag, "")
+	tag = repeatedDashesRegex.ReplaceAllString(tag, "-")
+	tag = strings.Trim(tag, "-")
+	if runes := []rune(tag); len(runes) > maxTagLength {
+		tag = strings.TrimRight(string(runes[:maxTagLength]), "-")
+	}
+	return tag
+}
+
+// ParseTags reads a comma-separated list of tags, normalizing them and
+// dropping empty and duplicate entries
+func ParseTags(raw string) []string {
+	tags := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		tag := NormalizeTag(part)
+		if tag == "" {
+			continue
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// SetFileTags replaces the tags of a file, removing the tags no note uses anymore
+func SetFileTags(ctx context.Context, db *pgx.Conn, username string, fileId int32, tags []string) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	queries := filesdb.New(db).WithTx(tx)
+	err = queries.DeleteFileTags(ctx, fileId)
+	if err != nil {
+		return err
+	}
+	for _, name := range tags {
+		tag, err := queries.UpsertTag(ctx, filesdb.UpsertTagParams{Username: username, Name: name})
+		if err != nil {
+			return err
+		}
+		err = queries.AddFileTag(ctx, filesdb.AddFileTagParams{FileID: fileId, TagID: tag.ID})
+		if err != nil {
+			return err
+		}
+	}
+	err = queries.DeleteUnusedTags(ctx, username)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GroupTagsByFile maps the id of each file to the names of its tags
+func GroupTagsByFile(rows []filesdb.GetUserFileTagsRow) map[int32][]string {
+	tags := map[int32][]string{}
+	for _, row := range rows {
+		tags[row.FileID] = append(tags[row.FileID], row.Name)
+	}
+	return tags
+}
@@ -0,0 +1,42 @@
+package files
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	testCases := map[string]string{
+		"Exam 1":        "exam-1",
+		"  to_review  ": "to-review",
+		"#important!":   "important",
+		"--a -- b--":    "a-b",
+		"Ünïcode Tag":   "ünïcode-tag",
+		"":              "",
+		"a-very-long-tag-name-that-goes-beyond-the-limit": "a-very-long-tag-name-that-goes-b",
+	}
+	for input, expected := range testCases {
+		if tag := NormalizeTag(input); tag != expected {
+			t.Errorf("Expecting %q to be normalized to %q, got %q", input, expected, tag)
+		}
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tags := ParseTags("Exam 1, to review,exam-1, , !!")
+	if !slices.Equal(tags, []string{"exam-1", "to-review"}) {
+		t.Errorf("Expecting the tags to be normalized and deduplicated, got %v", tags)
+	}
+	if tags := ParseTags(""); len(tags) != 0 {
+		t.Errorf("Expecting no tags from an empty input, got %v", tags)
+	}
+}
+
+func TestGroupTagsByFile(t *testing.T) {
+	grouped := GroupTagsByFile([]filesdb.GetUserFileTagsRow{{FileID: 1, Name: "exam-1"}, {FileID: 2, Name: "exam-1"}, {FileID: 1, Name: "to-review"}})
+	if !slices.Equal(grouped[1], []string{"exam-1", "to-review"}) || !slices.Equal(grouped[2], []string{"exam-1"}) {
+		t.Errorf("Expecting the tags to be grouped by file, got %v", grouped)
+	}
+}
@@ -0,0 +1,88 @@
+package files
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+// VersionGroupId returns the id shared by all the versions of a note, which
+// is the id of its first version
+func VersionGroupId(file filesdb.File) int32 {
+	if file.VersionGroupID.Valid {
+		return file.VersionGroupID.Int32
+	}
+	return file.ID
+}
+
+// LinkNewVersion records latest as the next version of previous, which stops
+// being listed among the notes but stays available in the version timeline
+func LinkNewVersion(ctx context.Context, db *pgx.Conn, previous filesdb.File, latest filesdb.File) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	queries := filesdb.New(db).WithTx(tx)
+	err = queries.SetFileVersion(ctx, filesdb.SetFileVersionParams{
+		ID:             latest.ID,
+		VersionGroupID: pgtype.Int4{Int32: VersionGroupId(previous), Valid: true},
+		Version:        previous.Version + 1,
+	})
+	if err != nil {
+		return err
+	}
+	err = queries.SupersedeFile(ctx, previous.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+type fileContent struct {
+	Url string `json:"url"`
+}
+
+// GetDownloadUrl returns a temporary URL to download a file uploaded to LlamaCloud
+func GetDownloadUrl(llamaCloudFileId string) (string, error) {
+	apiKey := os.Getenv("LLAMA_CLOUD_API_KEY")
+	req, err := http.NewRequest("GET", LlamaCloudBaseUrl()+"/api/v1/files/"+url.PathEscape(llamaCloudFileId)+"/content", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+apiKey)
+
+	client := &http.Client{}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d: %s", res.StatusCode, string(body))
+	}
+	var content fileContent
+	err = json.Unmarshal(body, &content)
+	if err != nil {
+		return "", err
+	}
+	if content.Url == "" {
+		return "", errors.New("LlamaCloud did not return a download URL")
+	}
+	return content.Url, nil
+}
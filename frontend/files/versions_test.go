@@ -0,0 +1,49 @@
+package files
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+func TestVersionGroupId(t *testing.T) {
+	first := filesdb.File{ID: 4, Version: 1}
+	if id := VersionGroupId(first); id != 4 {
+		t.Errorf("Expecting the first version to be its own group, got %d", id)
+	}
+	third := filesdb.File{ID: 9, Version: 3, VersionGroupID: pgtype.Int4{Int32: 4, Valid: true}}
+	if id := VersionGroupId(third); id != 4 {
+		t.Errorf("Expecting later versions to share the id of the first one, got %d", id)
+	}
+}
+
+func TestGetDownloadUrl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/files/file-1/content":
+			_, _ = w.Write([]byte(`{"url": "https://storage.example.com/file-1?signature=abc", "expires_at": "2026-01-01T00:00:00Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("LLAMA_CLOUD_BASE_URL", server.URL)
+	t.Setenv("LLAMA_CLOUD_API_KEY", "test-key")
+	downloadUrl, err := GetDownloadUrl("file-1")
+	if err != nil {
+		t.Fatalf("Not expecting an error, got %s", err.Error())
+	}
+	if downloadUrl != "https://storage.example.com/file-1?signature=abc" {
+		t.Errorf("Expecting the presigned URL to be returned, got %s", downloadUrl)
+	}
+	if _, err := GetDownloadUrl("missing"); err == nil {
+		t.Error("Expecting an error for a missing file")
+	}
+}
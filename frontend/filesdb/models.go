@@ -9,8 +9,129 @@ import (
 )
 
 type File struct {
-	ID           int32
-	Username     string
-	FileName     string
-	FileCategory pgtype.Text
+	ID               int32
+	Username         string
+	FileName         string
+	FileCategory     pgtype.Text
+	UploadedAt       pgtype.Timestamp
+	FileSize         pgtype.Int8
+	PageCount        pgtype.Int4
+	MimeType         pgtype.Text
+	LlamaCloudFileID pgtype.Text
+	DeletedAt        pgtype.Timestamp
+	Content          pgtype.Text
+	VersionGroupID   pgtype.Int4
+	Version          int32
+	SupersededAt     pgtype.Timestamp
+	CourseID         pgtype.Int4
+}
+
+type FileFaq struct {
+	ID       int32
+	FileID   int32
+	Question string
+	Answer   string
+}
+
+type FileNoteEdit struct {
+	ID          int32
+	FileID      int32
+	Description string
+	Snapshot    []byte
+	CreatedAt   pgtype.Timestamp
+}
+
+type FileSummary struct {
+	ID      int32
+	FileID  int32
+	Summary string
+}
+
+type FileTag struct {
+	FileID int32
+	TagID  int32
+}
+
+type NoteDraft struct {
+	ID        int32
+	Username  string
+	Title     string
+	Content   string
+	CreatedAt pgtype.Timestamp
+	UpdatedAt pgtype.Timestamp
+}
+
+type PendingCleanup struct {
+	ID               int32
+	Username         string
+	FileName         string
+	LlamaCloudFileID pgtype.Text
+	Attempts         int32
+	LastError        string
+	CreatedAt        pgtype.Timestamp
+}
+
+type SavedSearch struct {
+	ID                 int32
+	Username           string
+	SearchType         string
+	SearchInput        string
+	FileName           pgtype.Text
+	Category           pgtype.Text
+	CourseID           pgtype.Int4
+	Tags               []string
+	CreatedAt          pgtype.Timestamp
+	FileNames          []string
+	Categories         []string
+	ExcludedFileNames  []string
+	ExcludedCategories []string
+}
+
+type SearchAlert struct {
+	ID             int32
+	Username       string
+	SavedSearchID  int32
+	FileID         int32
+	Hits           int32
+	BestSimilarity float64
+	Excerpt        string
+	CreatedAt      pgtype.Timestamp
+	ReadAt         pgtype.Timestamp
+}
+
+type SearchHistory struct {
+	ID                 int32
+	Username           string
+	SearchType         string
+	SearchInput        string
+	FileName           pgtype.Text
+	Category           pgtype.Text
+	CourseID           pgtype.Int4
+	Tags               []string
+	ResultCount        int32
+	SearchedAt         pgtype.Timestamp
+	FileNames          []string
+	Categories         []string
+	ExcludedFileNames  []string
+	ExcludedCategories []string
+}
+
+type SearchHit struct {
+	ID             int32
+	Username       string
+	Category       string
+	Hits           int32
+	BestSimilarity float64
+	SearchedAt     pgtype.Timestamp
+}
+
+type SearchSetting struct {
+	Username       string
+	HistoryEnabled bool
+}
+
+type Tag struct {
+	ID       int32
+	Username string
+	Name     string
 }
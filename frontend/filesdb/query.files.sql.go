@@ -7,25 +7,346 @@ package filesdb
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const deleteFile = `-- name: DeleteFile :exec
-DELETE FROM files
-WHERE id = $1
+const addFileTag = `-- name: AddFileTag :exec
+INSERT INTO file_tags (
+  file_id, tag_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT DO NOTHING
 `
 
-func (q *Queries) DeleteFile(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteFile, id)
+type AddFileTagParams struct {
+	FileID int32
+	TagID  int32
+}
+
+func (q *Queries) AddFileTag(ctx context.Context, arg AddFileTagParams) error {
+	_, err := q.db.Exec(ctx, addFileTag,
+		arg.FileID,
+		arg.TagID,
+	)
 	return err
 }
 
-const getFiles = `-- name: GetFiles :many
-SELECT id, username, file_name, file_category FROM files
+const clearFilesCourse = `-- name: ClearFilesCourse :exec
+UPDATE files
+SET course_id = NULL
+WHERE username = $1 AND course_id = $2::int
+`
+
+type ClearFilesCourseParams struct {
+	Username string
+	CourseID int32
+}
+
+func (q *Queries) ClearFilesCourse(ctx context.Context, arg ClearFilesCourseParams) error {
+	_, err := q.db.Exec(ctx, clearFilesCourse,
+		arg.Username,
+		arg.CourseID,
+	)
+	return err
+}
+
+const clearSearchAlerts = `-- name: ClearSearchAlerts :exec
+DELETE FROM search_alerts
 WHERE username = $1
 `
 
-func (q *Queries) GetFiles(ctx context.Context, username string) ([]File, error) {
-	rows, err := q.db.Query(ctx, getFiles, username)
+func (q *Queries) ClearSearchAlerts(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, clearSearchAlerts, username)
+	return err
+}
+
+const clearSearchHistory = `-- name: ClearSearchHistory :exec
+DELETE FROM search_history
+WHERE username = $1
+`
+
+func (q *Queries) ClearSearchHistory(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, clearSearchHistory, username)
+	return err
+}
+
+const countFilesByName = `-- name: CountFilesByName :one
+SELECT COUNT(*) FROM files
+WHERE username = $1 AND file_name = $2 AND superseded_at IS NULL
+`
+
+type CountFilesByNameParams struct {
+	Username string
+	FileName string
+}
+
+func (q *Queries) CountFilesByName(ctx context.Context, arg CountFilesByNameParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countFilesByName,
+		arg.Username,
+		arg.FileName,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSavedSearches = `-- name: CountSavedSearches :one
+SELECT COUNT(*) FROM saved_searches
+WHERE username = $1
+`
+
+func (q *Queries) CountSavedSearches(ctx context.Context, username string) (int64, error) {
+	row := q.db.QueryRow(ctx, countSavedSearches, username)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnreadSearchAlerts = `-- name: CountUnreadSearchAlerts :one
+SELECT COUNT(*) FROM search_alerts
+JOIN files ON files.id = search_alerts.file_id
+WHERE search_alerts.username = $1 AND search_alerts.read_at IS NULL AND files.deleted_at IS NULL
+`
+
+func (q *Queries) CountUnreadSearchAlerts(ctx context.Context, username string) (int64, error) {
+	row := q.db.QueryRow(ctx, countUnreadSearchAlerts, username)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createFileFaq = `-- name: CreateFileFaq :one
+INSERT INTO file_faqs (
+  file_id, question, answer
+) VALUES (
+  $1, $2, $3
+)
+RETURNING id, file_id, question, answer
+`
+
+type CreateFileFaqParams struct {
+	FileID   int32
+	Question string
+	Answer   string
+}
+
+func (q *Queries) CreateFileFaq(ctx context.Context, arg CreateFileFaqParams) (FileFaq, error) {
+	row := q.db.QueryRow(ctx, createFileFaq,
+		arg.FileID,
+		arg.Question,
+		arg.Answer,
+	)
+	var i FileFaq
+	err := row.Scan(
+		&i.ID,
+		&i.FileID,
+		&i.Question,
+		&i.Answer,
+	)
+	return i, err
+}
+
+const createNoteDraft = `-- name: CreateNoteDraft :one
+INSERT INTO note_drafts (
+  username, title, content
+) VALUES (
+  $1, $2, $3
+)
+RETURNING id, username, title, content, created_at, updated_at
+`
+
+type CreateNoteDraftParams struct {
+	Username string
+	Title    string
+	Content  string
+}
+
+func (q *Queries) CreateNoteDraft(ctx context.Context, arg CreateNoteDraftParams) (NoteDraft, error) {
+	row := q.db.QueryRow(ctx, createNoteDraft,
+		arg.Username,
+		arg.Title,
+		arg.Content,
+	)
+	var i NoteDraft
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createNoteEdit = `-- name: CreateNoteEdit :exec
+INSERT INTO file_note_edits (
+  file_id, description, snapshot
+) VALUES (
+  $1, $2, $3
+)
+`
+
+type CreateNoteEditParams struct {
+	FileID      int32
+	Description string
+	Snapshot    []byte
+}
+
+func (q *Queries) CreateNoteEdit(ctx context.Context, arg CreateNoteEditParams) error {
+	_, err := q.db.Exec(ctx, createNoteEdit,
+		arg.FileID,
+		arg.Description,
+		arg.Snapshot,
+	)
+	return err
+}
+
+const createPendingCleanup = `-- name: CreatePendingCleanup :exec
+INSERT INTO pending_cleanups (
+  username, file_name, llama_cloud_file_id, last_error
+) VALUES (
+  $1, $2, $3, $4
+)
+`
+
+type CreatePendingCleanupParams struct {
+	Username         string
+	FileName         string
+	LlamaCloudFileID pgtype.Text
+	LastError        string
+}
+
+func (q *Queries) CreatePendingCleanup(ctx context.Context, arg CreatePendingCleanupParams) error {
+	_, err := q.db.Exec(ctx, createPendingCleanup,
+		arg.Username,
+		arg.FileName,
+		arg.LlamaCloudFileID,
+		arg.LastError,
+	)
+	return err
+}
+
+const createSavedSearch = `-- name: CreateSavedSearch :exec
+INSERT INTO saved_searches (username, search_type, search_input, file_name, category, course_id, tags, file_names, categories, excluded_file_names, excluded_categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+`
+
+type CreateSavedSearchParams struct {
+	Username           string
+	SearchType         string
+	SearchInput        string
+	FileName           pgtype.Text
+	Category           pgtype.Text
+	CourseID           pgtype.Int4
+	Tags               []string
+	FileNames          []string
+	Categories         []string
+	ExcludedFileNames  []string
+	ExcludedCategories []string
+}
+
+func (q *Queries) CreateSavedSearch(ctx context.Context, arg CreateSavedSearchParams) error {
+	_, err := q.db.Exec(ctx, createSavedSearch,
+		arg.Username,
+		arg.SearchType,
+		arg.SearchInput,
+		arg.FileName,
+		arg.Category,
+		arg.CourseID,
+		arg.Tags,
+		arg.FileNames,
+		arg.Categories,
+		arg.ExcludedFileNames,
+		arg.ExcludedCategories,
+	)
+	return err
+}
+
+const createSearchHistoryEntry = `-- name: CreateSearchHistoryEntry :exec
+INSERT INTO search_history (username, search_type, search_input, file_name, category, course_id, tags, result_count, file_names, categories, excluded_file_names, excluded_categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+`
+
+type CreateSearchHistoryEntryParams struct {
+	Username           string
+	SearchType         string
+	SearchInput        string
+	FileName           pgtype.Text
+	Category           pgtype.Text
+	CourseID           pgtype.Int4
+	Tags               []string
+	ResultCount        int32
+	FileNames          []string
+	Categories         []string
+	ExcludedFileNames  []string
+	ExcludedCategories []string
+}
+
+func (q *Queries) CreateSearchHistoryEntry(ctx context.Context, arg CreateSearchHistoryEntryParams) error {
+	_, err := q.db.Exec(ctx, createSearchHistoryEntry,
+		arg.Username,
+		arg.SearchType,
+		arg.SearchInput,
+		arg.FileName,
+		arg.Category,
+		arg.CourseID,
+		arg.Tags,
+		arg.ResultCount,
+		arg.FileNames,
+		arg.Categories,
+		arg.ExcludedFileNames,
+		arg.ExcludedCategories,
+	)
+	return err
+}
+
+const createSearchHit = `-- name: CreateSearchHit :exec
+INSERT INTO search_hits (username, category, hits, best_similarity)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateSearchHitParams struct {
+	Username       string
+	Category       string
+	Hits           int32
+	BestSimilarity float64
+}
+
+func (q *Queries) CreateSearchHit(ctx context.Context, arg CreateSearchHitParams) error {
+	_, err := q.db.Exec(ctx, createSearchHit,
+		arg.Username,
+		arg.Category,
+		arg.Hits,
+		arg.BestSimilarity,
+	)
+	return err
+}
+
+const deleteFile = `-- name: DeleteFile :many
+WITH note AS (
+  SELECT COALESCE(version_group_id, id) AS group_id FROM files
+  WHERE files.id = $1 AND files.username = $2
+)
+DELETE FROM files
+USING note
+WHERE files.username = $2 AND files.deleted_at IS NOT NULL
+  AND (files.id = note.group_id OR files.version_group_id = note.group_id)
+RETURNING files.id, files.username, files.file_name, files.file_category, files.uploaded_at, files.file_size, files.page_count, files.mime_type, files.llama_cloud_file_id, files.deleted_at, files.content, files.version_group_id, files.version, files.superseded_at, files.course_id
+`
+
+type DeleteFileParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) DeleteFile(ctx context.Context, arg DeleteFileParams) ([]File, error) {
+	rows, err := q.db.Query(ctx, deleteFile,
+		arg.ID,
+		arg.Username,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +359,278 @@ func (q *Queries) GetFiles(ctx context.Context, username string) ([]File, error)
 			&i.Username,
 			&i.FileName,
 			&i.FileCategory,
+			&i.UploadedAt,
+			&i.FileSize,
+			&i.PageCount,
+			&i.MimeType,
+			&i.LlamaCloudFileID,
+			&i.DeletedAt,
+			&i.Content,
+			&i.VersionGroupID,
+			&i.Version,
+			&i.SupersededAt,
+			&i.CourseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteFileFaq = `-- name: DeleteFileFaq :execrows
+DELETE FROM file_faqs
+WHERE id = $1 AND file_id = $2
+`
+
+type DeleteFileFaqParams struct {
+	ID     int32
+	FileID int32
+}
+
+func (q *Queries) DeleteFileFaq(ctx context.Context, arg DeleteFileFaqParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFileFaq,
+		arg.ID,
+		arg.FileID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteFileFaqs = `-- name: DeleteFileFaqs :exec
+DELETE FROM file_faqs
+WHERE file_id = $1
+`
+
+func (q *Queries) DeleteFileFaqs(ctx context.Context, fileID int32) error {
+	_, err := q.db.Exec(ctx, deleteFileFaqs, fileID)
+	return err
+}
+
+const deleteFileTags = `-- name: DeleteFileTags :exec
+DELETE FROM file_tags
+WHERE file_id = $1
+`
+
+func (q *Queries) DeleteFileTags(ctx context.Context, fileID int32) error {
+	_, err := q.db.Exec(ctx, deleteFileTags, fileID)
+	return err
+}
+
+const deleteNoteDraft = `-- name: DeleteNoteDraft :exec
+DELETE FROM note_drafts
+WHERE id = $1 AND username = $2
+`
+
+type DeleteNoteDraftParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) DeleteNoteDraft(ctx context.Context, arg DeleteNoteDraftParams) error {
+	_, err := q.db.Exec(ctx, deleteNoteDraft,
+		arg.ID,
+		arg.Username,
+	)
+	return err
+}
+
+const deletePendingCleanup = `-- name: DeletePendingCleanup :exec
+DELETE FROM pending_cleanups
+WHERE id = $1
+`
+
+func (q *Queries) DeletePendingCleanup(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, deletePendingCleanup, id)
+	return err
+}
+
+const deleteSavedSearch = `-- name: DeleteSavedSearch :execrows
+DELETE FROM saved_searches
+WHERE id = $1 AND username = $2
+`
+
+type DeleteSavedSearchParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) DeleteSavedSearch(ctx context.Context, arg DeleteSavedSearchParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSavedSearch,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSearchAlert = `-- name: DeleteSearchAlert :execrows
+DELETE FROM search_alerts
+WHERE id = $1 AND username = $2
+`
+
+type DeleteSearchAlertParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) DeleteSearchAlert(ctx context.Context, arg DeleteSearchAlertParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSearchAlert,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteSearchHistoryEntry = `-- name: DeleteSearchHistoryEntry :execrows
+DELETE FROM search_history
+WHERE id = $1 AND username = $2
+`
+
+type DeleteSearchHistoryEntryParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) DeleteSearchHistoryEntry(ctx context.Context, arg DeleteSearchHistoryEntryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSearchHistoryEntry,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteUnusedTags = `-- name: DeleteUnusedTags :exec
+DELETE FROM tags
+WHERE username = $1 AND NOT EXISTS (
+  SELECT 1 FROM file_tags WHERE file_tags.tag_id = tags.id
+)
+`
+
+func (q *Queries) DeleteUnusedTags(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, deleteUnusedTags, username)
+	return err
+}
+
+const getCategoryFaqCounts = `-- name: GetCategoryFaqCounts :many
+SELECT files.file_category, COUNT(file_faqs.id) AS faq_count FROM file_faqs
+JOIN files ON files.id = file_faqs.file_id
+WHERE files.username = $1 AND files.file_category IS NOT NULL AND files.deleted_at IS NULL AND files.superseded_at IS NULL
+GROUP BY files.file_category
+`
+
+type GetCategoryFaqCountsRow struct {
+	FileCategory pgtype.Text
+	FaqCount     int64
+}
+
+func (q *Queries) GetCategoryFaqCounts(ctx context.Context, username string) ([]GetCategoryFaqCountsRow, error) {
+	rows, err := q.db.Query(ctx, getCategoryFaqCounts, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategoryFaqCountsRow
+	for rows.Next() {
+		var i GetCategoryFaqCountsRow
+		if err := rows.Scan(
+			&i.FileCategory,
+			&i.FaqCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategoryNoteStats = `-- name: GetCategoryNoteStats :many
+SELECT file_category, COUNT(*) AS note_count, MAX(uploaded_at)::timestamp AS last_upload FROM files
+WHERE username = $1 AND file_category IS NOT NULL AND deleted_at IS NULL AND superseded_at IS NULL
+GROUP BY file_category
+`
+
+type GetCategoryNoteStatsRow struct {
+	FileCategory pgtype.Text
+	NoteCount    int64
+	LastUpload   pgtype.Timestamp
+}
+
+func (q *Queries) GetCategoryNoteStats(ctx context.Context, username string) ([]GetCategoryNoteStatsRow, error) {
+	rows, err := q.db.Query(ctx, getCategoryNoteStats, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategoryNoteStatsRow
+	for rows.Next() {
+		var i GetCategoryNoteStatsRow
+		if err := rows.Scan(
+			&i.FileCategory,
+			&i.NoteCount,
+			&i.LastUpload,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategorySearchStats = `-- name: GetCategorySearchStats :many
+SELECT category, COUNT(*) AS search_count, SUM(hits)::bigint AS hit_count, AVG(best_similarity)::float AS avg_similarity FROM search_hits
+WHERE username = $1 AND searched_at >= $2::timestamp
+GROUP BY category
+`
+
+type GetCategorySearchStatsParams struct {
+	Username string
+	Since    pgtype.Timestamp
+}
+
+type GetCategorySearchStatsRow struct {
+	Category      string
+	SearchCount   int64
+	HitCount      int64
+	AvgSimilarity float64
+}
+
+func (q *Queries) GetCategorySearchStats(ctx context.Context, arg GetCategorySearchStatsParams) ([]GetCategorySearchStatsRow, error) {
+	rows, err := q.db.Query(ctx, getCategorySearchStats,
+		arg.Username,
+		arg.Since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategorySearchStatsRow
+	for rows.Next() {
+		var i GetCategorySearchStatsRow
+		if err := rows.Scan(
+			&i.Category,
+			&i.SearchCount,
+			&i.HitCount,
+			&i.AvgSimilarity,
 		); err != nil {
 			return nil, err
 		}
@@ -48,3 +641,1586 @@ func (q *Queries) GetFiles(ctx context.Context, username string) ([]File, error)
 	}
 	return items, nil
 }
+
+const getCourseFileNames = `-- name: GetCourseFileNames :many
+SELECT DISTINCT file_name FROM files
+WHERE username = $1
+  AND deleted_at IS NULL
+  AND superseded_at IS NULL
+  AND course_id = ANY($2::int[])
+`
+
+type GetCourseFileNamesParams struct {
+	Username  string
+	CourseIds []int32
+}
+
+func (q *Queries) GetCourseFileNames(ctx context.Context, arg GetCourseFileNamesParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, getCourseFileNames,
+		arg.Username,
+		arg.CourseIds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var file_name string
+		if err := rows.Scan(&file_name); err != nil {
+			return nil, err
+		}
+		items = append(items, file_name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedFiles = `-- name: GetDeletedFiles :many
+SELECT id, username, file_name, file_category, uploaded_at, file_size, page_count, mime_type, llama_cloud_file_id, deleted_at, content, version_group_id, version, superseded_at, course_id FROM files
+WHERE username = $1 AND deleted_at IS NOT NULL AND superseded_at IS NULL
+ORDER BY deleted_at DESC
+`
+
+func (q *Queries) GetDeletedFiles(ctx context.Context, username string) ([]File, error) {
+	rows, err := q.db.Query(ctx, getDeletedFiles, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []File
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.FileName,
+			&i.FileCategory,
+			&i.UploadedAt,
+			&i.FileSize,
+			&i.PageCount,
+			&i.MimeType,
+			&i.LlamaCloudFileID,
+			&i.DeletedAt,
+			&i.Content,
+			&i.VersionGroupID,
+			&i.Version,
+			&i.SupersededAt,
+			&i.CourseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFile = `-- name: GetFile :one
+SELECT id, username, file_name, file_category, uploaded_at, file_size, page_count, mime_type, llama_cloud_file_id, deleted_at, content, version_group_id, version, superseded_at, course_id FROM files
+WHERE id = $1 AND username = $2 AND deleted_at IS NULL
+LIMIT 1
+`
+
+type GetFileParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) GetFile(ctx context.Context, arg GetFileParams) (File, error) {
+	row := q.db.QueryRow(ctx, getFile,
+		arg.ID,
+		arg.Username,
+	)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FileName,
+		&i.FileCategory,
+		&i.UploadedAt,
+		&i.FileSize,
+		&i.PageCount,
+		&i.MimeType,
+		&i.LlamaCloudFileID,
+		&i.DeletedAt,
+		&i.Content,
+		&i.VersionGroupID,
+		&i.Version,
+		&i.SupersededAt,
+		&i.CourseID,
+	)
+	return i, err
+}
+
+const getFileCategories = `-- name: GetFileCategories :many
+SELECT DISTINCT file_category FROM files
+WHERE username = $1 AND file_category IS NOT NULL AND deleted_at IS NULL AND superseded_at IS NULL
+ORDER BY file_category
+`
+
+func (q *Queries) GetFileCategories(ctx context.Context, username string) ([]pgtype.Text, error) {
+	rows, err := q.db.Query(ctx, getFileCategories, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.Text
+	for rows.Next() {
+		var file_category pgtype.Text
+		if err := rows.Scan(&file_category); err != nil {
+			return nil, err
+		}
+		items = append(items, file_category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFileFaqs = `-- name: GetFileFaqs :many
+SELECT id, file_id, question, answer FROM file_faqs
+WHERE file_id = $1
+ORDER BY id
+`
+
+func (q *Queries) GetFileFaqs(ctx context.Context, fileID int32) ([]FileFaq, error) {
+	rows, err := q.db.Query(ctx, getFileFaqs, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FileFaq
+	for rows.Next() {
+		var i FileFaq
+		if err := rows.Scan(
+			&i.ID,
+			&i.FileID,
+			&i.Question,
+			&i.Answer,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFileSummary = `-- name: GetFileSummary :one
+SELECT id, file_id, summary FROM file_summaries
+WHERE file_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetFileSummary(ctx context.Context, fileID int32) (FileSummary, error) {
+	row := q.db.QueryRow(ctx, getFileSummary, fileID)
+	var i FileSummary
+	err := row.Scan(
+		&i.ID,
+		&i.FileID,
+		&i.Summary,
+	)
+	return i, err
+}
+
+const getFileTags = `-- name: GetFileTags :many
+SELECT tags.name FROM tags
+JOIN file_tags ON file_tags.tag_id = tags.id
+WHERE file_tags.file_id = $1
+ORDER BY tags.name
+`
+
+func (q *Queries) GetFileTags(ctx context.Context, fileID int32) ([]string, error) {
+	rows, err := q.db.Query(ctx, getFileTags, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFileTypes = `-- name: GetFileTypes :many
+SELECT DISTINCT mime_type FROM files
+WHERE username = $1 AND mime_type IS NOT NULL AND deleted_at IS NULL AND superseded_at IS NULL
+ORDER BY mime_type
+`
+
+func (q *Queries) GetFileTypes(ctx context.Context, username string) ([]pgtype.Text, error) {
+	rows, err := q.db.Query(ctx, getFileTypes, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.Text
+	for rows.Next() {
+		var mime_type pgtype.Text
+		if err := rows.Scan(&mime_type); err != nil {
+			return nil, err
+		}
+		items = append(items, mime_type)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFileVersions = `-- name: GetFileVersions :many
+SELECT id, username, file_name, file_category, uploaded_at, file_size, page_count, mime_type, llama_cloud_file_id, deleted_at, content, version_group_id, version, superseded_at, course_id FROM files
+WHERE username = $1 AND deleted_at IS NULL AND (id = $2 OR version_group_id = $2)
+ORDER BY version DESC
+`
+
+type GetFileVersionsParams struct {
+	Username string
+	ID       int32
+}
+
+func (q *Queries) GetFileVersions(ctx context.Context, arg GetFileVersionsParams) ([]File, error) {
+	rows, err := q.db.Query(ctx, getFileVersions,
+		arg.Username,
+		arg.ID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []File
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.FileName,
+			&i.FileCategory,
+			&i.UploadedAt,
+			&i.FileSize,
+			&i.PageCount,
+			&i.MimeType,
+			&i.LlamaCloudFileID,
+			&i.DeletedAt,
+			&i.Content,
+			&i.VersionGroupID,
+			&i.Version,
+			&i.SupersededAt,
+			&i.CourseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFiles = `-- name: GetFiles :many
+SELECT id, username, file_name, file_category, uploaded_at, file_size, page_count, mime_type, llama_cloud_file_id, deleted_at, content, version_group_id, version, superseded_at, course_id FROM files
+WHERE username = $1 AND deleted_at IS NULL AND superseded_at IS NULL
+`
+
+func (q *Queries) GetFiles(ctx context.Context, username string) ([]File, error) {
+	rows, err := q.db.Query(ctx, getFiles, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []File
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.FileName,
+			&i.FileCategory,
+			&i.UploadedAt,
+			&i.FileSize,
+			&i.PageCount,
+			&i.MimeType,
+			&i.LlamaCloudFileID,
+			&i.DeletedAt,
+			&i.Content,
+			&i.VersionGroupID,
+			&i.Version,
+			&i.SupersededAt,
+			&i.CourseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNoteDraft = `-- name: GetNoteDraft :one
+SELECT id, username, title, content, created_at, updated_at FROM note_drafts
+WHERE id = $1 AND username = $2
+`
+
+type GetNoteDraftParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) GetNoteDraft(ctx context.Context, arg GetNoteDraftParams) (NoteDraft, error) {
+	row := q.db.QueryRow(ctx, getNoteDraft,
+		arg.ID,
+		arg.Username,
+	)
+	var i NoteDraft
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getNoteDrafts = `-- name: GetNoteDrafts :many
+SELECT id, username, title, content, created_at, updated_at FROM note_drafts
+WHERE username = $1
+ORDER BY updated_at DESC
+`
+
+func (q *Queries) GetNoteDrafts(ctx context.Context, username string) ([]NoteDraft, error) {
+	rows, err := q.db.Query(ctx, getNoteDrafts, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []NoteDraft
+	for rows.Next() {
+		var i NoteDraft
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNoteEdit = `-- name: GetNoteEdit :one
+SELECT id, file_id, description, snapshot, created_at FROM file_note_edits
+WHERE id = $1 AND file_id = $2
+LIMIT 1
+`
+
+type GetNoteEditParams struct {
+	ID     int32
+	FileID int32
+}
+
+func (q *Queries) GetNoteEdit(ctx context.Context, arg GetNoteEditParams) (FileNoteEdit, error) {
+	row := q.db.QueryRow(ctx, getNoteEdit,
+		arg.ID,
+		arg.FileID,
+	)
+	var i FileNoteEdit
+	err := row.Scan(
+		&i.ID,
+		&i.FileID,
+		&i.Description,
+		&i.Snapshot,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getNoteEdits = `-- name: GetNoteEdits :many
+SELECT id, file_id, description, snapshot, created_at FROM file_note_edits
+WHERE file_id = $1
+ORDER BY id DESC
+LIMIT 20
+`
+
+func (q *Queries) GetNoteEdits(ctx context.Context, fileID int32) ([]FileNoteEdit, error) {
+	rows, err := q.db.Query(ctx, getNoteEdits, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FileNoteEdit
+	for rows.Next() {
+		var i FileNoteEdit
+		if err := rows.Scan(
+			&i.ID,
+			&i.FileID,
+			&i.Description,
+			&i.Snapshot,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPendingCleanups = `-- name: GetPendingCleanups :many
+SELECT id, username, file_name, llama_cloud_file_id, attempts, last_error, created_at FROM pending_cleanups
+WHERE attempts < $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetPendingCleanups(ctx context.Context, attempts int32) ([]PendingCleanup, error) {
+	rows, err := q.db.Query(ctx, getPendingCleanups, attempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PendingCleanup
+	for rows.Next() {
+		var i PendingCleanup
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.FileName,
+			&i.LlamaCloudFileID,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSavedSearch = `-- name: GetSavedSearch :one
+SELECT id, username, search_type, search_input, file_name, category, course_id, tags, created_at, file_names, categories, excluded_file_names, excluded_categories FROM saved_searches
+WHERE id = $1 AND username = $2
+`
+
+type GetSavedSearchParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) GetSavedSearch(ctx context.Context, arg GetSavedSearchParams) (SavedSearch, error) {
+	row := q.db.QueryRow(ctx, getSavedSearch,
+		arg.ID,
+		arg.Username,
+	)
+	var i SavedSearch
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.SearchType,
+		&i.SearchInput,
+		&i.FileName,
+		&i.Category,
+		&i.CourseID,
+		&i.Tags,
+		&i.CreatedAt,
+		&i.FileNames,
+		&i.Categories,
+		&i.ExcludedFileNames,
+		&i.ExcludedCategories,
+	)
+	return i, err
+}
+
+const getSavedSearches = `-- name: GetSavedSearches :many
+SELECT id, username, search_type, search_input, file_name, category, course_id, tags, created_at, file_names, categories, excluded_file_names, excluded_categories FROM saved_searches
+WHERE username = $1
+ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) GetSavedSearches(ctx context.Context, username string) ([]SavedSearch, error) {
+	rows, err := q.db.Query(ctx, getSavedSearches, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SavedSearch
+	for rows.Next() {
+		var i SavedSearch
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.SearchType,
+			&i.SearchInput,
+			&i.FileName,
+			&i.Category,
+			&i.CourseID,
+			&i.Tags,
+			&i.CreatedAt,
+			&i.FileNames,
+			&i.Categories,
+			&i.ExcludedFileNames,
+			&i.ExcludedCategories,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSearchAlerts = `-- name: GetSearchAlerts :many
+SELECT search_alerts.id, search_alerts.saved_search_id, search_alerts.file_id, search_alerts.hits, search_alerts.best_similarity, search_alerts.excerpt, search_alerts.created_at, search_alerts.read_at, saved_searches.search_input, files.file_name FROM search_alerts
+JOIN saved_searches ON saved_searches.id = search_alerts.saved_search_id
+JOIN files ON files.id = search_alerts.file_id
+WHERE search_alerts.username = $1 AND files.deleted_at IS NULL
+ORDER BY search_alerts.created_at DESC, search_alerts.id DESC
+LIMIT $2
+`
+
+type GetSearchAlertsParams struct {
+	Username string
+	Limit    int32
+}
+
+type GetSearchAlertsRow struct {
+	ID             int32
+	SavedSearchID  int32
+	FileID         int32
+	Hits           int32
+	BestSimilarity float64
+	Excerpt        string
+	CreatedAt      pgtype.Timestamp
+	ReadAt         pgtype.Timestamp
+	SearchInput    string
+	FileName       string
+}
+
+func (q *Queries) GetSearchAlerts(ctx context.Context, arg GetSearchAlertsParams) ([]GetSearchAlertsRow, error) {
+	rows, err := q.db.Query(ctx, getSearchAlerts,
+		arg.Username,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSearchAlertsRow
+	for rows.Next() {
+		var i GetSearchAlertsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SavedSearchID,
+			&i.FileID,
+			&i.Hits,
+			&i.BestSimilarity,
+			&i.Excerpt,
+			&i.CreatedAt,
+			&i.ReadAt,
+			&i.SearchInput,
+			&i.FileName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSearchHistory = `-- name: GetSearchHistory :many
+SELECT id, username, search_type, search_input, file_name, category, course_id, tags, result_count, searched_at, file_names, categories, excluded_file_names, excluded_categories FROM search_history
+WHERE username = $1
+ORDER BY searched_at DESC, id DESC
+LIMIT $2
+`
+
+type GetSearchHistoryParams struct {
+	Username string
+	Limit    int32
+}
+
+func (q *Queries) GetSearchHistory(ctx context.Context, arg GetSearchHistoryParams) ([]SearchHistory, error) {
+	rows, err := q.db.Query(ctx, getSearchHistory,
+		arg.Username,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchHistory
+	for rows.Next() {
+		var i SearchHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.SearchType,
+			&i.SearchInput,
+			&i.FileName,
+			&i.Category,
+			&i.CourseID,
+			&i.Tags,
+			&i.ResultCount,
+			&i.SearchedAt,
+			&i.FileNames,
+			&i.Categories,
+			&i.ExcludedFileNames,
+			&i.ExcludedCategories,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSearchHistoryEnabled = `-- name: GetSearchHistoryEnabled :one
+SELECT history_enabled FROM search_settings
+WHERE username = $1
+`
+
+func (q *Queries) GetSearchHistoryEnabled(ctx context.Context, username string) (bool, error) {
+	row := q.db.QueryRow(ctx, getSearchHistoryEnabled, username)
+	var history_enabled bool
+	err := row.Scan(&history_enabled)
+	return history_enabled, err
+}
+
+const getSearchHistoryEntry = `-- name: GetSearchHistoryEntry :one
+SELECT id, username, search_type, search_input, file_name, category, course_id, tags, result_count, searched_at, file_names, categories, excluded_file_names, excluded_categories FROM search_history
+WHERE id = $1 AND username = $2
+`
+
+type GetSearchHistoryEntryParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) GetSearchHistoryEntry(ctx context.Context, arg GetSearchHistoryEntryParams) (SearchHistory, error) {
+	row := q.db.QueryRow(ctx, getSearchHistoryEntry,
+		arg.ID,
+		arg.Username,
+	)
+	var i SearchHistory
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.SearchType,
+		&i.SearchInput,
+		&i.FileName,
+		&i.Category,
+		&i.CourseID,
+		&i.Tags,
+		&i.ResultCount,
+		&i.SearchedAt,
+		&i.FileNames,
+		&i.Categories,
+		&i.ExcludedFileNames,
+		&i.ExcludedCategories,
+	)
+	return i, err
+}
+
+const getTaggedFileNames = `-- name: GetTaggedFileNames :many
+SELECT DISTINCT files.file_name FROM files
+JOIN file_tags ON file_tags.file_id = files.id
+JOIN tags ON tags.id = file_tags.tag_id
+WHERE files.username = $1
+  AND files.deleted_at IS NULL
+  AND files.superseded_at IS NULL
+  AND tags.name = ANY($2::text[])
+`
+
+type GetTaggedFileNamesParams struct {
+	Username string
+	Tags     []string
+}
+
+func (q *Queries) GetTaggedFileNames(ctx context.Context, arg GetTaggedFileNamesParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, getTaggedFileNames,
+		arg.Username,
+		arg.Tags,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var file_name string
+		if err := rows.Scan(&file_name); err != nil {
+			return nil, err
+		}
+		items = append(items, file_name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTags = `-- name: GetTags :many
+SELECT name FROM tags
+WHERE username = $1
+ORDER BY name
+`
+
+func (q *Queries) GetTags(ctx context.Context, username string) ([]string, error) {
+	rows, err := q.db.Query(ctx, getTags, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserFileTags = `-- name: GetUserFileTags :many
+SELECT file_tags.file_id, tags.name FROM tags
+JOIN file_tags ON file_tags.tag_id = tags.id
+WHERE tags.username = $1
+ORDER BY tags.name
+`
+
+type GetUserFileTagsRow struct {
+	FileID int32
+	Name   string
+}
+
+func (q *Queries) GetUserFileTags(ctx context.Context, username string) ([]GetUserFileTagsRow, error) {
+	rows, err := q.db.Query(ctx, getUserFileTags, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserFileTagsRow
+	for rows.Next() {
+		var i GetUserFileTagsRow
+		if err := rows.Scan(
+			&i.FileID,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWeeklyUploads = `-- name: GetWeeklyUploads :many
+SELECT date_trunc('week', uploaded_at)::timestamp AS week, COUNT(*) AS note_count FROM files
+WHERE username = $1 AND deleted_at IS NULL AND superseded_at IS NULL AND uploaded_at >= $2::timestamp
+GROUP BY week
+ORDER BY week
+`
+
+type GetWeeklyUploadsParams struct {
+	Username string
+	Since    pgtype.Timestamp
+}
+
+type GetWeeklyUploadsRow struct {
+	Week      pgtype.Timestamp
+	NoteCount int64
+}
+
+func (q *Queries) GetWeeklyUploads(ctx context.Context, arg GetWeeklyUploadsParams) ([]GetWeeklyUploadsRow, error) {
+	rows, err := q.db.Query(ctx, getWeeklyUploads,
+		arg.Username,
+		arg.Since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWeeklyUploadsRow
+	for rows.Next() {
+		var i GetWeeklyUploadsRow
+		if err := rows.Scan(
+			&i.Week,
+			&i.NoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const keywordSearchNotes = `-- name: KeywordSearchNotes :many
+SELECT files.file_name, files.file_category, matches.result_type, matches.text, matches.rank FROM (
+  SELECT file_id, 'summary'::text AS result_type, summary AS text,
+    ts_rank(to_tsvector('english', summary), websearch_to_tsquery('english', $1::text), 32)::float AS rank
+  FROM file_summaries
+  WHERE $2::bool AND to_tsvector('english', summary) @@ websearch_to_tsquery('english', $1::text)
+  UNION ALL
+  SELECT file_id, 'answer'::text, answer,
+    ts_rank(to_tsvector('english', question || ' ' || answer), websearch_to_tsquery('english', $1::text), 32)::float
+  FROM file_faqs
+  WHERE $3::bool AND to_tsvector('english', question || ' ' || answer) @@ websearch_to_tsquery('english', $1::text)
+) AS matches
+JOIN files ON files.id = matches.file_id
+WHERE files.username = $4 AND files.deleted_at IS NULL AND files.superseded_at IS NULL
+  AND (cardinality($5::text[]) = 0 OR files.file_name = ANY($5::text[]))
+  AND (cardinality($6::text[]) = 0 OR files.file_category = ANY($6::text[]))
+  AND NOT files.file_name = ANY($7::text[])
+  AND (files.file_category IS NULL OR NOT files.file_category = ANY($8::text[]))
+  AND ($9::int IS NULL OR files.course_id = $9::int)
+  AND (cardinality($10::text[]) = 0 OR EXISTS (
+    SELECT 1 FROM file_tags
+    JOIN tags ON tags.id = file_tags.tag_id
+    WHERE file_tags.file_id = files.id AND tags.name = ANY($10::text[])
+  ))
+ORDER BY matches.rank DESC, files.id DESC
+LIMIT $11::int OFFSET $12::int
+`
+
+type KeywordSearchNotesParams struct {
+	Query              string
+	Summaries          bool
+	Faqs               bool
+	Username           string
+	FileNames          []string
+	Categories         []string
+	ExcludedFileNames  []string
+	ExcludedCategories []string
+	CourseID           pgtype.Int4
+	Tags               []string
+	MaxResults         int32
+	Skip               int32
+}
+
+type KeywordSearchNotesRow struct {
+	FileName     string
+	FileCategory pgtype.Text
+	ResultType   string
+	Text         string
+	Rank         float64
+}
+
+func (q *Queries) KeywordSearchNotes(ctx context.Context, arg KeywordSearchNotesParams) ([]KeywordSearchNotesRow, error) {
+	rows, err := q.db.Query(ctx, keywordSearchNotes,
+		arg.Query,
+		arg.Summaries,
+		arg.Faqs,
+		arg.Username,
+		arg.FileNames,
+		arg.Categories,
+		arg.ExcludedFileNames,
+		arg.ExcludedCategories,
+		arg.CourseID,
+		arg.Tags,
+		arg.MaxResults,
+		arg.Skip,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []KeywordSearchNotesRow
+	for rows.Next() {
+		var i KeywordSearchNotesRow
+		if err := rows.Scan(
+			&i.FileName,
+			&i.FileCategory,
+			&i.ResultType,
+			&i.Text,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFiles = `-- name: ListFiles :many
+SELECT id, username, file_name, file_category, uploaded_at, file_size, page_count, mime_type, llama_cloud_file_id, deleted_at, content, version_group_id, version, superseded_at, course_id FROM files
+WHERE username = $1
+  AND deleted_at IS NULL
+  AND superseded_at IS NULL
+  AND ($2::text IS NULL OR file_category = $2 OR file_category = ANY($3::text[]))
+  AND ($4::text IS NULL OR mime_type = $4)
+  AND ($5::text IS NULL OR EXISTS (
+    SELECT 1 FROM file_tags
+    JOIN tags ON tags.id = file_tags.tag_id
+    WHERE file_tags.file_id = files.id AND tags.name = $5
+  ))
+  AND ($6::int IS NULL OR course_id = $6)
+  AND (course_id IS NULL OR NOT course_id = ANY($7::int[]))
+ORDER BY
+  CASE WHEN $8::text = 'date' AND NOT $9::boolean THEN uploaded_at END ASC NULLS LAST,
+  CASE WHEN $8::text = 'date' AND $9::boolean THEN uploaded_at END DESC NULLS LAST,
+  CASE WHEN $8::text = 'size' AND NOT $9::boolean THEN file_size END ASC NULLS LAST,
+  CASE WHEN $8::text = 'size' AND $9::boolean THEN file_size END DESC NULLS LAST,
+  CASE WHEN $8::text = 'name' AND NOT $9::boolean THEN file_name END ASC,
+  CASE WHEN $8::text = 'name' AND $9::boolean THEN file_name END DESC,
+  id ASC
+`
+
+type ListFilesParams struct {
+	Username        string
+	FileCategory    pgtype.Text
+	Subcategories   []string
+	MimeType        pgtype.Text
+	Tag             pgtype.Text
+	CourseID        pgtype.Int4
+	ExcludedCourses []int32
+	SortBy          string
+	SortDesc        bool
+}
+
+func (q *Queries) ListFiles(ctx context.Context, arg ListFilesParams) ([]File, error) {
+	rows, err := q.db.Query(ctx, listFiles,
+		arg.Username,
+		arg.FileCategory,
+		arg.Subcategories,
+		arg.MimeType,
+		arg.Tag,
+		arg.CourseID,
+		arg.ExcludedCourses,
+		arg.SortBy,
+		arg.SortDesc,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []File
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.FileName,
+			&i.FileCategory,
+			&i.UploadedAt,
+			&i.FileSize,
+			&i.PageCount,
+			&i.MimeType,
+			&i.LlamaCloudFileID,
+			&i.DeletedAt,
+			&i.Content,
+			&i.VersionGroupID,
+			&i.Version,
+			&i.SupersededAt,
+			&i.CourseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markSearchAlertsRead = `-- name: MarkSearchAlertsRead :exec
+UPDATE search_alerts
+SET read_at = CURRENT_TIMESTAMP
+WHERE username = $1 AND read_at IS NULL
+`
+
+func (q *Queries) MarkSearchAlertsRead(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, markSearchAlertsRead, username)
+	return err
+}
+
+const purgeDeletedFiles = `-- name: PurgeDeletedFiles :many
+DELETE FROM files
+WHERE (deleted_at IS NOT NULL AND deleted_at < $1)
+  OR (superseded_at IS NOT NULL AND NOT EXISTS (
+    SELECT 1 FROM files AS latest
+    WHERE latest.superseded_at IS NULL
+      AND latest.version_group_id = COALESCE(files.version_group_id, files.id)
+  ))
+RETURNING id, username, file_name, file_category, uploaded_at, file_size, page_count, mime_type, llama_cloud_file_id, deleted_at, content, version_group_id, version, superseded_at, course_id
+`
+
+func (q *Queries) PurgeDeletedFiles(ctx context.Context, deletedAt pgtype.Timestamp) ([]File, error) {
+	rows, err := q.db.Query(ctx, purgeDeletedFiles, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []File
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.FileName,
+			&i.FileCategory,
+			&i.UploadedAt,
+			&i.FileSize,
+			&i.PageCount,
+			&i.MimeType,
+			&i.LlamaCloudFileID,
+			&i.DeletedAt,
+			&i.Content,
+			&i.VersionGroupID,
+			&i.Version,
+			&i.SupersededAt,
+			&i.CourseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const renameFileCategory = `-- name: RenameFileCategory :many
+UPDATE files
+SET file_category = $1::text
+WHERE username = $2 AND file_category = $3::text
+RETURNING id, username, file_name, file_category, uploaded_at, file_size, page_count, mime_type, llama_cloud_file_id, deleted_at, content, version_group_id, version, superseded_at, course_id
+`
+
+type RenameFileCategoryParams struct {
+	NewCategory string
+	Username    string
+	OldCategory string
+}
+
+func (q *Queries) RenameFileCategory(ctx context.Context, arg RenameFileCategoryParams) ([]File, error) {
+	rows, err := q.db.Query(ctx, renameFileCategory,
+		arg.NewCategory,
+		arg.Username,
+		arg.OldCategory,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []File
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.FileName,
+			&i.FileCategory,
+			&i.UploadedAt,
+			&i.FileSize,
+			&i.PageCount,
+			&i.MimeType,
+			&i.LlamaCloudFileID,
+			&i.DeletedAt,
+			&i.Content,
+			&i.VersionGroupID,
+			&i.Version,
+			&i.SupersededAt,
+			&i.CourseID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const renameSearchHitsCategory = `-- name: RenameSearchHitsCategory :exec
+UPDATE search_hits
+SET category = $1::text
+WHERE username = $2 AND category = $3::text
+`
+
+type RenameSearchHitsCategoryParams struct {
+	NewCategory string
+	Username    string
+	OldCategory string
+}
+
+func (q *Queries) RenameSearchHitsCategory(ctx context.Context, arg RenameSearchHitsCategoryParams) error {
+	_, err := q.db.Exec(ctx, renameSearchHitsCategory,
+		arg.NewCategory,
+		arg.Username,
+		arg.OldCategory,
+	)
+	return err
+}
+
+const restoreFile = `-- name: RestoreFile :execrows
+WITH note AS (
+  SELECT COALESCE(version_group_id, id) AS group_id FROM files
+  WHERE files.id = $1 AND files.username = $2
+)
+UPDATE files
+SET deleted_at = NULL
+FROM note
+WHERE files.username = $2 AND files.deleted_at IS NOT NULL
+  AND (files.id = note.group_id OR files.version_group_id = note.group_id)
+`
+
+type RestoreFileParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) RestoreFile(ctx context.Context, arg RestoreFileParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreFile,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setCategoryCourse = `-- name: SetCategoryCourse :exec
+UPDATE files
+SET course_id = $1
+WHERE username = $2 AND file_category = $3::text
+`
+
+type SetCategoryCourseParams struct {
+	CourseID     pgtype.Int4
+	Username     string
+	FileCategory string
+}
+
+func (q *Queries) SetCategoryCourse(ctx context.Context, arg SetCategoryCourseParams) error {
+	_, err := q.db.Exec(ctx, setCategoryCourse,
+		arg.CourseID,
+		arg.Username,
+		arg.FileCategory,
+	)
+	return err
+}
+
+const setFileContent = `-- name: SetFileContent :exec
+UPDATE files
+SET content = $2
+WHERE id = $1
+`
+
+type SetFileContentParams struct {
+	ID      int32
+	Content pgtype.Text
+}
+
+func (q *Queries) SetFileContent(ctx context.Context, arg SetFileContentParams) error {
+	_, err := q.db.Exec(ctx, setFileContent,
+		arg.ID,
+		arg.Content,
+	)
+	return err
+}
+
+const setFileCourse = `-- name: SetFileCourse :exec
+UPDATE files
+SET course_id = $2
+WHERE id = $1
+`
+
+type SetFileCourseParams struct {
+	ID       int32
+	CourseID pgtype.Int4
+}
+
+func (q *Queries) SetFileCourse(ctx context.Context, arg SetFileCourseParams) error {
+	_, err := q.db.Exec(ctx, setFileCourse,
+		arg.ID,
+		arg.CourseID,
+	)
+	return err
+}
+
+const setFileVersion = `-- name: SetFileVersion :exec
+UPDATE files
+SET version_group_id = $2, version = $3
+WHERE id = $1
+`
+
+type SetFileVersionParams struct {
+	ID             int32
+	VersionGroupID pgtype.Int4
+	Version        int32
+}
+
+func (q *Queries) SetFileVersion(ctx context.Context, arg SetFileVersionParams) error {
+	_, err := q.db.Exec(ctx, setFileVersion,
+		arg.ID,
+		arg.VersionGroupID,
+		arg.Version,
+	)
+	return err
+}
+
+const setSearchHistoryEnabled = `-- name: SetSearchHistoryEnabled :exec
+INSERT INTO search_settings (username, history_enabled)
+VALUES ($1, $2)
+ON CONFLICT (username) DO UPDATE SET history_enabled = EXCLUDED.history_enabled
+`
+
+type SetSearchHistoryEnabledParams struct {
+	Username       string
+	HistoryEnabled bool
+}
+
+func (q *Queries) SetSearchHistoryEnabled(ctx context.Context, arg SetSearchHistoryEnabledParams) error {
+	_, err := q.db.Exec(ctx, setSearchHistoryEnabled,
+		arg.Username,
+		arg.HistoryEnabled,
+	)
+	return err
+}
+
+const softDeleteFile = `-- name: SoftDeleteFile :execrows
+WITH note AS (
+  SELECT COALESCE(version_group_id, id) AS group_id FROM files
+  WHERE files.id = $1 AND files.username = $2
+)
+UPDATE files
+SET deleted_at = CURRENT_TIMESTAMP
+FROM note
+WHERE files.username = $2 AND files.deleted_at IS NULL
+  AND (files.id = note.group_id OR files.version_group_id = note.group_id)
+`
+
+type SoftDeleteFileParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) SoftDeleteFile(ctx context.Context, arg SoftDeleteFileParams) (int64, error) {
+	result, err := q.db.Exec(ctx, softDeleteFile,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const supersedeFile = `-- name: SupersedeFile :exec
+UPDATE files
+SET superseded_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+func (q *Queries) SupersedeFile(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, supersedeFile, id)
+	return err
+}
+
+const touchSearchHistoryEntry = `-- name: TouchSearchHistoryEntry :exec
+UPDATE search_history
+SET searched_at = CURRENT_TIMESTAMP, result_count = $3
+WHERE id = $1 AND username = $2
+`
+
+type TouchSearchHistoryEntryParams struct {
+	ID          int32
+	Username    string
+	ResultCount int32
+}
+
+func (q *Queries) TouchSearchHistoryEntry(ctx context.Context, arg TouchSearchHistoryEntryParams) error {
+	_, err := q.db.Exec(ctx, touchSearchHistoryEntry,
+		arg.ID,
+		arg.Username,
+		arg.ResultCount,
+	)
+	return err
+}
+
+const trimSearchHistory = `-- name: TrimSearchHistory :exec
+DELETE FROM search_history
+WHERE username = $1 AND id NOT IN (
+  SELECT id FROM search_history
+  WHERE username = $1
+  ORDER BY searched_at DESC, id DESC
+  LIMIT $2::int
+)
+`
+
+type TrimSearchHistoryParams struct {
+	Username string
+	Keep     int32
+}
+
+func (q *Queries) TrimSearchHistory(ctx context.Context, arg TrimSearchHistoryParams) error {
+	_, err := q.db.Exec(ctx, trimSearchHistory,
+		arg.Username,
+		arg.Keep,
+	)
+	return err
+}
+
+const updateFileFaq = `-- name: UpdateFileFaq :execrows
+UPDATE file_faqs
+SET question = $1,
+    answer = $2
+WHERE id = $3 AND file_id = $4
+`
+
+type UpdateFileFaqParams struct {
+	Question string
+	Answer   string
+	ID       int32
+	FileID   int32
+}
+
+func (q *Queries) UpdateFileFaq(ctx context.Context, arg UpdateFileFaqParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateFileFaq,
+		arg.Question,
+		arg.Answer,
+		arg.ID,
+		arg.FileID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateFileMetadata = `-- name: UpdateFileMetadata :one
+UPDATE files
+SET uploaded_at = COALESCE($1, uploaded_at),
+    file_size = $2,
+    page_count = $3,
+    mime_type = $4
+WHERE username = $5 AND llama_cloud_file_id = $6
+RETURNING id, username, file_name, file_category, uploaded_at, file_size, page_count, mime_type, llama_cloud_file_id, deleted_at, content, version_group_id, version, superseded_at, course_id
+`
+
+type UpdateFileMetadataParams struct {
+	UploadedAt       pgtype.Timestamp
+	FileSize         pgtype.Int8
+	PageCount        pgtype.Int4
+	MimeType         pgtype.Text
+	Username         string
+	LlamaCloudFileID pgtype.Text
+}
+
+func (q *Queries) UpdateFileMetadata(ctx context.Context, arg UpdateFileMetadataParams) (File, error) {
+	row := q.db.QueryRow(ctx, updateFileMetadata,
+		arg.UploadedAt,
+		arg.FileSize,
+		arg.PageCount,
+		arg.MimeType,
+		arg.Username,
+		arg.LlamaCloudFileID,
+	)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FileName,
+		&i.FileCategory,
+		&i.UploadedAt,
+		&i.FileSize,
+		&i.PageCount,
+		&i.MimeType,
+		&i.LlamaCloudFileID,
+		&i.DeletedAt,
+		&i.Content,
+		&i.VersionGroupID,
+		&i.Version,
+		&i.SupersededAt,
+		&i.CourseID,
+	)
+	return i, err
+}
+
+const updateNoteDraft = `-- name: UpdateNoteDraft :one
+UPDATE note_drafts
+SET title = $3, content = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND username = $2
+RETURNING id, username, title, content, created_at, updated_at
+`
+
+type UpdateNoteDraftParams struct {
+	ID       int32
+	Username string
+	Title    string
+	Content  string
+}
+
+func (q *Queries) UpdateNoteDraft(ctx context.Context, arg UpdateNoteDraftParams) (NoteDraft, error) {
+	row := q.db.QueryRow(ctx, updateNoteDraft,
+		arg.ID,
+		arg.Username,
+		arg.Title,
+		arg.Content,
+	)
+	var i NoteDraft
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updatePendingCleanup = `-- name: UpdatePendingCleanup :exec
+UPDATE pending_cleanups
+SET attempts = attempts + 1, last_error = $2
+WHERE id = $1
+`
+
+type UpdatePendingCleanupParams struct {
+	ID        int32
+	LastError string
+}
+
+func (q *Queries) UpdatePendingCleanup(ctx context.Context, arg UpdatePendingCleanupParams) error {
+	_, err := q.db.Exec(ctx, updatePendingCleanup,
+		arg.ID,
+		arg.LastError,
+	)
+	return err
+}
+
+const upsertFileSummary = `-- name: UpsertFileSummary :exec
+INSERT INTO file_summaries (
+  file_id, summary
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (file_id) DO UPDATE
+SET summary = EXCLUDED.summary
+`
+
+type UpsertFileSummaryParams struct {
+	FileID  int32
+	Summary string
+}
+
+func (q *Queries) UpsertFileSummary(ctx context.Context, arg UpsertFileSummaryParams) error {
+	_, err := q.db.Exec(ctx, upsertFileSummary,
+		arg.FileID,
+		arg.Summary,
+	)
+	return err
+}
+
+const upsertSearchAlert = `-- name: UpsertSearchAlert :exec
+INSERT INTO search_alerts (username, saved_search_id, file_id, hits, best_similarity, excerpt)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (saved_search_id, file_id) DO UPDATE
+SET hits = EXCLUDED.hits, best_similarity = EXCLUDED.best_similarity, excerpt = EXCLUDED.excerpt, created_at = CURRENT_TIMESTAMP, read_at = NULL
+`
+
+type UpsertSearchAlertParams struct {
+	Username       string
+	SavedSearchID  int32
+	FileID         int32
+	Hits           int32
+	BestSimilarity float64
+	Excerpt        string
+}
+
+func (q *Queries) UpsertSearchAlert(ctx context.Context, arg UpsertSearchAlertParams) error {
+	_, err := q.db.Exec(ctx, upsertSearchAlert,
+		arg.Username,
+		arg.SavedSearchID,
+		arg.FileID,
+		arg.Hits,
+		arg.BestSimilarity,
+		arg.Excerpt,
+	)
+	return err
+}
+
+const upsertTag = `-- name: UpsertTag :one
+INSERT INTO tags (
+  username, name
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (username, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id, username, name
+`
+
+type UpsertTagParams struct {
+	Username string
+	Name     string
+}
+
+func (q *Queries) UpsertTag(ctx context.Context, arg UpsertTagParams) (Tag, error) {
+	row := q.db.QueryRow(ctx, upsertTag,
+		arg.Username,
+		arg.Name,
+	)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Name,
+	)
+	return i, err
+}
@@ -8,7 +8,10 @@ require (
 	github.com/gofiber/storage/sqlite3 v1.3.8
 	github.com/jackc/pgx/v5 v5.7.6
 	github.com/valyala/fasthttp v1.51.0
-	golang.org/x/crypto v0.37.0
+	github.com/yuin/goldmark v1.7.13
+	golang.org/x/crypto v0.40.0
+	golang.org/x/net v0.42.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -27,5 +30,5 @@ require (
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/tcplisten v1.0.0 // indirect
 	golang.org/x/sys v0.34.0 // indirect
-	golang.org/x/text v0.24.0 // indirect
+	golang.org/x/text v0.27.0 // indirect
 )
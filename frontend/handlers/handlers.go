@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,6 +24,7 @@ import (
 	"github.com/run-llama/study-llama/frontend/rules"
 	"github.com/run-llama/study-llama/frontend/rulesdb"
 	"github.com/run-llama/study-llama/frontend/templates"
+	"github.com/run-llama/study-llama/frontend/trash"
 )
 
 func HandleSignUp(c *fiber.Ctx) error {
@@ -124,57 +131,179 @@ func HandleLogout(c *fiber.Ctx) error {
 	}
 }
 
+// renderRuleFormError shows an error inside the create or edit category modal
+// instead of replacing the categories list, so that the modal stays open
+func renderRuleFormError(c *fiber.Ctx, target string, err error) error {
+	c.Set("HX-Retarget", target)
+	c.Set("HX-Reswap", "innerHTML")
+	return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+}
+
 func HandleCreateRule(c *fiber.Ctx) error {
 	user, err := auth.AuthorizePost(c)
 	if err != nil {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
 	ruleName := c.FormValue("rule_name")
-	ruleType := c.FormValue("rule_type")
+	ruleType := strings.TrimSpace(c.FormValue("rule_type"))
 	ruleDes := c.FormValue("rule_description")
+	labelKey, err := rules.CanonicalLabel(ruleType)
+	if err != nil {
+		return renderRuleFormError(c, "#create-rule-status", err)
+	}
 	db, err := rules.CreateNewDb()
 	if err != nil {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
 	queries := rulesdb.New(db)
-	rules, err := queries.GetRules(context.Background(), user.Username)
+	userRules, err := queries.GetRules(context.Background(), user.Username)
 	if err != nil {
 		if !errors.Is(err, pgx.ErrNoRows) {
 			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 		}
 	}
-	rule, err := queries.CreateRule(context.Background(), rulesdb.CreateRuleParams{Username: user.Username, RuleName: ruleName, RuleType: ruleType, RuleDescription: ruleDes})
+	parentId := formParentId(c)
+	if err := rules.ValidateParent(userRules, 0, parentId); err != nil {
+		return renderRuleFormError(c, "#create-rule-status", err)
+	}
+	rule, err := queries.CreateRule(context.Background(), rulesdb.CreateRuleParams{Username: user.Username, RuleName: ruleName, RuleType: labelKey, DisplayLabel: ruleType, RuleDescription: ruleDes, ParentID: pgtype.Int4{Int32: parentId, Valid: parentId != 0}})
+	if err != nil {
+		if conflict := rules.RuleConflict(err); conflict != nil {
+			return renderRuleFormError(c, "#create-rule-status", conflict)
+		}
+		return renderRuleFormError(c, "#create-rule-status", err)
+	}
+	userRules = append(userRules, rule)
+	stats, err := getCategoryStats(user.Username)
 	if err != nil {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	rules = append(rules, rule)
-	return templates.RulesList(rules).Render(c.Context(), c.Response().BodyWriter())
+	return templates.RulesListUpdate(userRules, stats).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// formParentId reads the parent category selected in a category form, 0
+// standing for a top-level category
+func formParentId(c *fiber.Ctx) int32 {
+	parentId, err := strconv.Atoi(c.FormValue("parent_id"))
+	if err != nil || parentId < 0 {
+		return 0
+	}
+	return int32(parentId)
 }
 
+// HandleUpdateRule updates the category identified by the :id parameter. When
+// its label changes, the notes classified in it are moved to the new label.
 func HandleUpdateRule(c *fiber.Ctx) error {
 	user, err := auth.AuthorizePost(c)
 	if err != nil {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
+	ruleIdInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return renderRuleFormError(c, "#edit-rule-status", err)
+	}
 	ruleName := c.FormValue("rule_name")
-	ruleType := c.FormValue("rule_type")
+	ruleType := strings.TrimSpace(c.FormValue("rule_type"))
 	ruleDes := c.FormValue("rule_description")
+	labelKey, err := rules.CanonicalLabel(ruleType)
+	if err != nil {
+		return renderRuleFormError(c, "#edit-rule-status", err)
+	}
 	db, err := rules.CreateNewDb()
 	if err != nil {
-		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		return renderRuleFormError(c, "#edit-rule-status", err)
 	}
 	queries := rulesdb.New(db)
-	err = queries.UpdateRule(context.Background(), rulesdb.UpdateRuleParams{Username: user.Username, RuleName: ruleName, RuleType: ruleType, RuleDescription: ruleDes})
+	previous, err := queries.GetRule(context.Background(), rulesdb.GetRuleParams{ID: int32(ruleIdInt), Username: user.Username})
 	if err != nil {
-		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		if errors.Is(err, pgx.ErrNoRows) {
+			return renderRuleFormError(c, "#edit-rule-status", errors.New("this category does not exist"))
+		}
+		return renderRuleFormError(c, "#edit-rule-status", err)
 	}
-	rules, err := queries.GetRules(context.Background(), user.Username)
+	allRules, err := queries.GetAllRules(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return renderRuleFormError(c, "#edit-rule-status", err)
+	}
+	parentId := formParentId(c)
+	if err := rules.ValidateParent(allRules, previous.ID, parentId); err != nil {
+		return renderRuleFormError(c, "#edit-rule-status", err)
+	}
+	updated, err := queries.UpdateRule(context.Background(), rulesdb.UpdateRuleParams{RuleName: ruleName, RuleType: labelKey, DisplayLabel: ruleType, RuleDescription: ruleDes, ParentID: pgtype.Int4{Int32: parentId, Valid: parentId != 0}, ID: previous.ID, Username: user.Username})
+	if err != nil {
+		if conflict := rules.RuleConflict(err); conflict != nil {
+			return renderRuleFormError(c, "#edit-rule-status", conflict)
+		}
+		return renderRuleFormError(c, "#edit-rule-status", err)
+	}
+	if err := renameCategory(user.Username, rules.NormalizeLabel(previous.RuleType), rules.NormalizeLabel(updated.RuleType)); err != nil {
+		return renderRuleFormError(c, "#edit-rule-status", err)
+	}
+	userRules, err := queries.GetRules(context.Background(), user.Username)
 	if err != nil {
 		if !errors.Is(err, pgx.ErrNoRows) {
 			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 		}
 	}
-	return templates.RulesList(rules).Render(c.Context(), c.Response().BodyWriter())
+	stats, err := getCategoryStats(user.Username)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.RulesListUpdate(userRules, stats).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// renameCategory moves the notes of a category to its new label, and re-indexes
+// them so that searches filtered by category keep finding them
+func renameCategory(username string, previousLabel string, label string) error {
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return err
+	}
+	queries := filesdb.New(db)
+	renamed, err := relabelNotes(context.Background(), queries, username, previousLabel, label)
+	if err != nil {
+		return err
+	}
+	if failed := reindexRenamed(queries, renamed); failed > 0 {
+		return fmt.Errorf("the category was renamed, but %d of its notes could not be re-indexed", failed)
+	}
+	return nil
+}
+
+// relabelNotes moves the notes and the search statistics of a category to its
+// new label, and returns the moved notes
+func relabelNotes(ctx context.Context, queries *filesdb.Queries, username string, previousLabel string, label string) ([]filesdb.File, error) {
+	if previousLabel == label {
+		return nil, nil
+	}
+	renamed, err := queries.RenameFileCategory(ctx, filesdb.RenameFileCategoryParams{NewCategory: label, Username: username, OldCategory: previousLabel})
+	if err != nil {
+		return nil, err
+	}
+	err = queries.RenameSearchHitsCategory(ctx, filesdb.RenameSearchHitsCategoryParams{NewCategory: label, Username: username, OldCategory: previousLabel})
+	if err != nil {
+		return nil, err
+	}
+	return renamed, nil
+}
+
+// reindexRenamed re-indexes the latest versions of the notes moved to a new
+// label, and returns how many of them could not be re-indexed
+func reindexRenamed(queries *filesdb.Queries, renamed []filesdb.File) int {
+	failed := 0
+	for _, file := range renamed {
+		if file.SupersededAt.Valid {
+			continue
+		}
+		notes, err := files.LoadStudyNotes(context.Background(), queries, file.ID)
+		if err == nil {
+			err = reindexNote(file, notes)
+		}
+		if err != nil {
+			failed++
+		}
+	}
+	return failed
 }
 
 func HandleDeleteRule(c *fiber.Ctx) error {
@@ -192,17 +321,24 @@ func HandleDeleteRule(c *fiber.Ctx) error {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
 	queries := rulesdb.New(db)
-	err = queries.DeleteRule(context.Background(), int32(ruleIdInt))
+	deleted, err := queries.SoftDeleteRule(context.Background(), rulesdb.SoftDeleteRuleParams{ID: int32(ruleIdInt), Username: user.Username})
 	if err != nil {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	rules, err := queries.GetRules(context.Background(), user.Username)
+	if deleted == 0 {
+		return templates.StatusBanner(errors.New("this category does not exist")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	userRules, err := queries.GetRules(context.Background(), user.Username)
 	if err != nil {
 		if !errors.Is(err, pgx.ErrNoRows) {
 			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 		}
 	}
-	return templates.RulesList(rules).Render(c.Context(), c.Response().BodyWriter())
+	stats, err := getCategoryStats(user.Username)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.RulesListUpdate(userRules, stats).Render(c.Context(), c.Response().BodyWriter())
 }
 
 func HandleUploadFile(c *fiber.Ctx) error {
@@ -220,119 +356,159 @@ func HandleUploadFile(c *fiber.Ctx) error {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
 	defer func() { _ = src.Close() }()
-	fileId, err := files.UploadFile(src, file.Filename)
-	if err != nil {
-		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
-	}
-	response, err := agent.ProcessFile(agent.InputFileEvent{FileId: fileId, FileName: file.Filename, Username: user.Username})
+	return ingestFile(c, user.Username, src, file.Filename)
+}
+
+func HandleImportUrl(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
 	if err != nil {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	if response.GetErrorString() != nil {
-		return templates.StatusBanner(errors.New(*response.GetErrorString())).Render(c.Context(), c.Response().BodyWriter())
-	}
-	db, err := files.CreateNewDb()
-	if err != nil {
-		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	importUrl := c.FormValue("import_url")
+	if importUrl == "" {
+		return templates.StatusBanner(errors.New("please provide a URL to import")).Render(c.Context(), c.Response().BodyWriter())
 	}
-	queries := filesdb.New(db)
-	files, err := queries.GetFiles(context.Background(), user.Username)
+	doc, err := files.NewImporter().Fetch(context.Background(), importUrl)
 	if err != nil {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	return templates.FilesList(files).Render(c.Context(), c.Response().BodyWriter())
+	return ingestFile(c, user.Username, bytes.NewReader(doc.Content), doc.FileName)
 }
 
-func HandleDeleteFile(c *fiber.Ctx) error {
-	user, err := auth.AuthorizePost(c)
-	c.Set("Content-Type", "text/html")
+// ingest uploads a file to LlamaCloud, runs the classify and extract workflow
+// on it and stores its metadata and study notes
+func ingest(username string, src io.Reader, fileName string) (*filesdb.File, error) {
+	uploaded, err := files.UploadFile(src, fileName)
 	if err != nil {
-		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		return nil, err
 	}
-	fileId := c.Params("id")
-	fileIdInt, err := strconv.Atoi(fileId)
+	response, err := agent.ProcessFile(agent.InputFileEvent{FileId: uploaded.ID, FileName: fileName, Username: username})
 	if err != nil {
-		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		return nil, err
+	}
+	if response.GetErrorString() != nil {
+		return nil, errors.New(*response.GetErrorString())
 	}
 	db, err := files.CreateNewDb()
 	if err != nil {
-		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		return nil, err
 	}
 	queries := filesdb.New(db)
-	err = queries.DeleteFile(context.Background(), int32(fileIdInt))
+	uploadedFile, err := queries.UpdateFileMetadata(context.Background(), filesdb.UpdateFileMetadataParams{UploadedAt: uploaded.GetUploadedAt(), FileSize: uploaded.GetFileSize(), PageCount: uploaded.GetPageCount(), MimeType: uploaded.GetMimeType(), Username: username, LlamaCloudFileID: pgtype.Text{String: uploaded.ID, Valid: true}})
 	if err != nil {
-		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		return nil, err
 	}
-	files, err := queries.GetFiles(context.Background(), user.Username)
-	if err != nil {
-		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	if uploadedFile.FileCategory.Valid {
+		courseId, err := getCategoryCourse(username, uploadedFile.FileCategory.String)
+		if err != nil {
+			return nil, err
+		}
+		if courseId.Valid {
+			err = queries.SetFileCourse(context.Background(), filesdb.SetFileCourseParams{ID: uploadedFile.ID, CourseID: courseId})
+			if err != nil {
+				return nil, err
+			}
+			uploadedFile.CourseID = courseId
+		}
+	}
+	summary, faqs := response.GetStudyNotes()
+	if summary != nil {
+		notes := files.StudyNotes{Summary: *summary, Faqs: make([]files.NoteFaq, 0, len(faqs))}
+		for _, faq := range faqs {
+			notes.Faqs = append(notes.Faqs, files.NoteFaq{Question: faq.Question, Answer: faq.Answer})
+		}
+		err = files.SaveStudyNotes(context.Background(), db, uploadedFile.ID, notes)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return templates.FilesList(files).Render(c.Context(), c.Response().BodyWriter())
+	// the search index is up to date with the note, check it against the
+	// saved searches without holding up the upload
+	go func() {
+		if err := alertSavedSearches(username, uploadedFile); err != nil {
+			log.Printf("could not check the saved searches of %s: %s", username, err.Error())
+		}
+	}()
+	return &uploadedFile, nil
 }
 
-func HandleSearch(c *fiber.Ctx) error {
-	user, err := auth.AuthorizePost(c)
-	c.Set("Content-Type", "text/html")
+// ingestFile ingests a file and renders the updated notes list
+func ingestFile(c *fiber.Ctx, username string, src io.Reader, fileName string) error {
+	_, err := ingest(username, src, fileName)
 	if err != nil {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	searchType := c.FormValue("search_type")
-	searchInput := c.FormValue("search_input")
-	fileName := c.FormValue("file_name") // only one file name is allowed (can be empty)
-	category := c.FormValue("category")  // select among available categories (can be empty)
-	var categoryFilter *string
-	var fileNameFilter *string
-	if category == "" {
-		categoryFilter = nil
-	} else {
-		categoryFilter = &category
-	}
-	if fileName == "" {
-		fileNameFilter = nil
-	} else {
-		fileNameFilter = &fileName
-	}
-	searchResult, err := agent.ProcessSearch(agent.SearchInputEvent{SearchType: searchType, SearchInput: searchInput, Category: categoryFilter, FileName: fileNameFilter, Username: user.Username})
+	db, err := files.CreateNewDb()
 	if err != nil {
 		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	return templates.SearchResultsList(searchResult.GetResults()).Render(c.Context(), c.Response().BodyWriter())
+	return renderFilesList(c, filesdb.New(db), username)
 }
 
-func LoginRoute(c *fiber.Ctx) error {
-	if c.Method() != fiber.MethodGet {
-		return c.SendStatus(fiber.StatusMethodNotAllowed)
+// getCategoryCourse returns the course of the category a note was classified in
+func getCategoryCourse(username string, category string) (pgtype.Int4, error) {
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return pgtype.Int4{}, err
 	}
-	c.Set("Content-Type", "text/html")
-	return templates.SignIn().Render(c.Context(), c.Response().BodyWriter())
+	userRules, err := rulesdb.New(db).GetRules(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.Int4{}, err
+	}
+	return rules.CourseForCategory(userRules, category), nil
 }
 
-func SignUpRoute(c *fiber.Ctx) error {
-	if c.Method() != fiber.MethodGet {
-		return c.SendStatus(fiber.StatusMethodNotAllowed)
+// getArchivedCourseIds returns the courses whose notes are hidden unless explicitly selected
+func getArchivedCourseIds(username string) ([]int32, error) {
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return nil, err
 	}
-	c.Set("Content-Type", "text/html")
-	return templates.SignUp().Render(c.Context(), c.Response().BodyWriter())
+	archived, err := rulesdb.New(db).GetArchivedCourseIds(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return archived, nil
 }
 
-func PageDoesNotExistRoute(c *fiber.Ctx) error {
-	if c.Method() != fiber.MethodGet {
-		return c.SendStatus(fiber.StatusMethodNotAllowed)
+// getCategoryTree returns the categories of a user, which the notes are grouped by
+func getCategoryTree(username string) ([]rulesdb.Rule, error) {
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return nil, err
 	}
-	c.Set("Content-Type", "text/html")
-	return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+	userRules, err := rulesdb.New(db).GetAllRules(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return userRules, nil
 }
 
-func HomeRoute(c *fiber.Ctx) error {
-	if c.Method() != fiber.MethodGet {
-		return c.SendStatus(fiber.StatusMethodNotAllowed)
+// renderFilesList renders the notes of a user, with their tags, in the default order
+func renderFilesList(c *fiber.Ctx, queries *filesdb.Queries, username string) error {
+	archived, err := getArchivedCourseIds(username)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	_, err := auth.AuthorizeGet(c)
-	c.Set("Content-Type", "text/html")
-	return templates.Home(err == nil).Render(c.Context(), c.Response().BodyWriter())
+	userRules, err := getCategoryTree(username)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	opts := files.ParseListOptions("", "", "", "", "", "")
+	opts.ExcludedCourses = archived
+	fileList, err := queries.ListFiles(context.Background(), opts.Params(username))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	fileTags, err := queries.GetUserFileTags(context.Background(), username)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.FilesList(fileList, files.GroupTagsByFile(fileTags), userRules).Render(c.Context(), c.Response().BodyWriter())
 }
 
-func CategoriesRoute(c *fiber.Ctx) error {
+func NoteWriterRoute(c *fiber.Ctx) error {
 	if c.Method() != fiber.MethodGet {
 		return c.SendStatus(fiber.StatusMethodNotAllowed)
 	}
@@ -341,78 +517,2060 @@ func CategoriesRoute(c *fiber.Ctx) error {
 	if err != nil {
 		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
 	}
-	db, err := rules.CreateNewDb()
+	db, err := files.CreateNewDb()
 	if err != nil {
 		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	queries := rulesdb.New(db)
-	rules, err := queries.GetRules(context.Background(), user.Username)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return templates.RulesPage(user.Username, []rulesdb.Rule{}).Render(c.Context(), c.Response().BodyWriter())
+	queries := filesdb.New(db)
+	var draft *filesdb.NoteDraft
+	if draftId := c.Query("draft"); draftId != "" {
+		draftIdInt, err := strconv.Atoi(draftId)
+		if err != nil {
+			return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+		}
+		noteDraft, err := queries.GetNoteDraft(context.Background(), filesdb.GetNoteDraftParams{ID: int32(draftIdInt), Username: user.Username})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+			}
+			return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
 		}
+		draft = &noteDraft
+	}
+	drafts, err := queries.GetNoteDrafts(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-
-	return templates.RulesPage(user.Username, rules).Render(c.Context(), c.Response().BodyWriter())
+	return templates.NoteWriterPage(draft, drafts).Render(c.Context(), c.Response().BodyWriter())
 }
 
-func FilesRoute(c *fiber.Ctx) error {
-	if c.Method() != fiber.MethodGet {
-		return c.SendStatus(fiber.StatusMethodNotAllowed)
+// HandleSaveDraft autosaves the note being written, creating the draft on the first save
+func HandleSaveDraft(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	user, err := auth.AuthorizeGet(c)
+	title := c.FormValue("title")
+	content := c.FormValue("content")
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	var draft filesdb.NoteDraft
+	if draftId := c.FormValue("draft_id"); draftId != "" {
+		draftIdInt, err := strconv.Atoi(draftId)
+		if err != nil {
+			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+		draft, err = queries.UpdateNoteDraft(context.Background(), filesdb.UpdateNoteDraftParams{ID: int32(draftIdInt), Username: user.Username, Title: title, Content: content})
+		if err != nil {
+			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+	} else {
+		if title == "" && content == "" {
+			return templates.DraftStatus(nil).Render(c.Context(), c.Response().BodyWriter())
+		}
+		draft, err = queries.CreateNoteDraft(context.Background(), filesdb.CreateNoteDraftParams{Username: user.Username, Title: title, Content: content})
+		if err != nil {
+			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+	}
+	return templates.DraftStatus(&draft).Render(c.Context(), c.Response().BodyWriter())
+}
+
+func HandleDeleteDraft(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
 	c.Set("Content-Type", "text/html")
 	if err != nil {
-		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	draftIdInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
 	db, err := files.CreateNewDb()
 	if err != nil {
-		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
 	queries := filesdb.New(db)
-	files, err := queries.GetFiles(context.Background(), user.Username)
+	err = queries.DeleteNoteDraft(context.Background(), filesdb.DeleteNoteDraftParams{ID: int32(draftIdInt), Username: user.Username})
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return templates.FilesPage([]filesdb.File{}).Render(c.Context(), c.Response().BodyWriter())
-		}
-		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	return templates.FilesPage(files).Render(c.Context(), c.Response().BodyWriter())
+	drafts, err := queries.GetNoteDrafts(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.DraftsList(drafts).Render(c.Context(), c.Response().BodyWriter())
 }
 
-func SearchRoute(c *fiber.Ctx) error {
-	if c.Method() != fiber.MethodGet {
-		return c.SendStatus(fiber.StatusMethodNotAllowed)
+func HandlePreviewNote(c *fiber.Ctx) error {
+	_, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	user, err := auth.AuthorizeGet(c)
+	return templates.Markdown(c.FormValue("content")).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// HandleCreateNote ingests a note written in the editor as a markdown upload
+// and keeps its markdown body, so it can be displayed on the note page
+func HandleCreateNote(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
 	c.Set("Content-Type", "text/html")
 	if err != nil {
-		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	db, err := rules.CreateNewDb()
+	title := c.FormValue("title")
+	content := c.FormValue("content")
+	if strings.TrimSpace(content) == "" {
+		return templates.StatusBanner(errors.New("the note is empty")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	file, err := ingest(user.Username, strings.NewReader(content), files.NoteFileName(title))
 	if err != nil {
-		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	queries := rulesdb.New(db)
-	rules, err := queries.GetRules(context.Background(), user.Username)
+	db, err := files.CreateNewDb()
 	if err != nil {
-		if !errors.Is(err, pgx.ErrNoRows) {
-			return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	err = queries.SetFileContent(context.Background(), filesdb.SetFileContentParams{ID: file.ID, Content: pgtype.Text{String: content, Valid: true}})
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if draftIdInt, err := strconv.Atoi(c.FormValue("draft_id")); err == nil {
+		err = queries.DeleteNoteDraft(context.Background(), filesdb.DeleteNoteDraftParams{ID: int32(draftIdInt), Username: user.Username})
+		if err != nil {
+			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 		}
-		rules = []rulesdb.Rule{}
 	}
-	dbFiles, err := files.CreateNewDb()
+	c.Set("HX-Redirect", "/notes/"+strconv.Itoa(int(file.ID)))
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func HandleDeleteFile(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
 	if err != nil {
-		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
 	}
-	queriesFiles := filesdb.New(dbFiles)
-	files, err := queriesFiles.GetFiles(context.Background(), user.Username)
+	fileId := c.Params("id")
+	fileIdInt, err := strconv.Atoi(fileId)
 	if err != nil {
-		if !errors.Is(err, pgx.ErrNoRows) {
-			return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
-		}
-		files = []filesdb.File{}
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	deleted, err := queries.SoftDeleteFile(context.Background(), filesdb.SoftDeleteFileParams{ID: int32(fileIdInt), Username: user.Username})
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if deleted == 0 {
+		return templates.StatusBanner(errors.New("this note does not exist")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return renderFilesList(c, queries, user.Username)
+}
+
+func HandleUpdateTags(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	fileIdInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	file, err := queries.GetFile(context.Background(), filesdb.GetFileParams{ID: int32(fileIdInt), Username: user.Username})
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	err = files.SetFileTags(context.Background(), db, user.Username, file.ID, files.ParseTags(c.FormValue("tags")))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	tags, err := queries.GetFileTags(context.Background(), file.ID)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.FileCard(file, tags).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// getCourseFileNames returns the names of the notes filed under the given courses
+func getCourseFileNames(username string, courseIds []int32) ([]string, error) {
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return nil, err
+	}
+	return filesdb.New(db).GetCourseFileNames(context.Background(), filesdb.GetCourseFileNamesParams{Username: username, CourseIds: courseIds})
+}
+
+// formValues returns the distinct values of a multi-valued form field, or nil
+// when it has none
+func formValues(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, value := range c.Request().PostArgs().PeekMulti(key) {
+		if v := strings.TrimSpace(string(value)); v != "" && !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// searchEventFromForm reads a search and its filters from the search form
+func searchEventFromForm(c *fiber.Ctx, username string) agent.SearchInputEvent {
+	searchType := c.FormValue("search_type")
+	searchInput := c.FormValue("search_input")
+	tags := []string{} // select among the user's tags, results must come from a note carrying one of them
+	for _, value := range c.Request().PostArgs().PeekMulti("tags") {
+		if tag := files.NormalizeTag(string(value)); tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	searchEvent := agent.SearchInputEvent{SearchType: searchType, SearchInput: searchInput, Username: username}
+	// select among the user's notes and categories, results must come from one of them and from none of the excluded ones (can be empty)
+	searchEvent.FileNames = formValues(c, "file_names")
+	searchEvent.Categories = formValues(c, "categories")
+	searchEvent.ExcludedFileNames = formValues(c, "excluded_file_names")
+	searchEvent.ExcludedCategories = formValues(c, "excluded_categories")
+	if len(tags) > 0 {
+		searchEvent.Tags = tags
+	}
+	courseId, err := strconv.Atoi(c.FormValue("course")) // scope the search to a course (can be empty)
+	if err == nil && courseId > 0 {
+		courseFilter := int32(courseId)
+		searchEvent.CourseID = &courseFilter
+	}
+	searchEvent.TopK, searchEvent.MinSimilarity, searchEvent.Offset = agent.ParsePaging(c.FormValue("top_k"), c.FormValue("min_similarity"), c.FormValue("offset"))
+	return searchEvent
+}
+
+func HandleSearch(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	searchEvent := searchEventFromForm(c, user.Username)
+	results, more, err := runSearch(searchEvent)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	next := nextPage(searchEvent, more)
+	if searchEvent.Offset > 0 {
+		// loading more results of a search already counted and saved
+		return templates.SearchResultsMore(results, next).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if err := recordSearchHits(user.Username, results); err != nil {
+		log.Printf("could not record the search statistics of %s: %s", user.Username, err.Error())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	enabled, err := files.SearchHistoryEnabled(context.Background(), queries, user.Username)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if !enabled {
+		return templates.SearchResultsList(results, next).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if err := files.SaveSearch(context.Background(), queries, agent.NewHistoryEntry(searchEvent, len(results))); err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	history, err := queries.GetSearchHistory(context.Background(), filesdb.GetSearchHistoryParams{Username: user.Username, Limit: files.ShownSearchHistory})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.SearchResultsWithHistory(results, next, history).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// nextPage is the search loading the next page of results, when there is one
+func nextPage(searchEvent agent.SearchInputEvent, more bool) *agent.SearchInputEvent {
+	if !more {
+		return nil
+	}
+	next := searchEvent.NextPage()
+	return &next
+}
+
+// runSearch runs a search, reporting whether more results follow the page.
+// The notes and categories in the trash and the notes of archived courses are
+// excluded in the request itself, along with the filters of the search, so
+// that the searches apply them all before paging.
+func runSearch(searchEvent agent.SearchInputEvent) ([]agent.SearchResult, bool, error) {
+	if searchEvent.Category != nil || len(searchEvent.Categories) > 0 || len(searchEvent.ExcludedCategories) > 0 {
+		// a category also matches the notes of its subcategories
+		userRules, err := getCategoryTree(searchEvent.Username)
+		if err != nil {
+			return nil, false, err
+		}
+		labels := searchEvent.Categories
+		if searchEvent.Category != nil {
+			labels = append(slices.Clone(labels), *searchEvent.Category)
+		}
+		if labels = rules.SubtreesLabels(userRules, labels); len(labels) > 1 {
+			searchEvent.Category = nil
+			searchEvent.Categories = labels
+		}
+		if len(searchEvent.ExcludedCategories) > 0 {
+			searchEvent.ExcludedCategories = rules.SubtreesLabels(userRules, searchEvent.ExcludedCategories)
+		}
+	}
+	trashedFiles, trashedCategories, err := getTrashedNames(searchEvent.Username)
+	if err != nil {
+		return nil, false, err
+	}
+	request := searchEvent
+	request.TopK = searchEvent.PageSize() + 1 // the extra result tells whether there is a next page
+	request.MinSimilarity = searchEvent.Threshold()
+	request.ExcludedFileNames = slices.Concat(searchEvent.ExcludedFileNames, trashedFiles)
+	request.ExcludedCategories = slices.Concat(searchEvent.ExcludedCategories, trashedCategories)
+	if searchEvent.CourseID == nil {
+		archived, err := getArchivedCourseIds(searchEvent.Username)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(archived) > 0 {
+			archivedFiles, err := getCourseFileNames(searchEvent.Username, archived)
+			if err != nil {
+				return nil, false, err
+			}
+			request.ExcludedFileNames = slices.Concat(request.ExcludedFileNames, archivedFiles)
+		}
+	}
+	var searchResult *agent.SearchResponseBody
+	switch searchEvent.SearchType {
+	case agent.SearchTypeKeyword:
+		searchResult, err = notesSearch(agent.KeywordSearch, request)
+	case agent.SearchTypeHybrid:
+		searchResult, err = notesSearch(agent.ProcessSearchHybrid, request)
+	case agent.SearchTypeAll:
+		searchResult, err = agent.ProcessSearchAll(request)
+	default:
+		searchResult, err = agent.ProcessSearch(request)
+	}
+	if err == nil && searchResult.Error != nil {
+		err = errors.New(*searchResult.Error)
+	}
+	if err != nil && searchEvent.SearchType != agent.SearchTypeKeyword {
+		// the keyword search of the notes stands in for the search backend
+		log.Printf("the search of %s failed, falling back to a keyword search: %s", searchEvent.Username, err.Error())
+		searchResult, err = notesSearch(agent.KeywordSearch, request)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	results, more := searchResult.Page(searchEvent)
+	agent.HighlightResults(results, searchEvent.SearchInput)
+	return results, more, nil
+}
+
+// searchHistoryId parses the :id parameter of the search history routes
+func searchHistoryId(c *fiber.Ctx) (int32, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return 0, errors.New("invalid search id")
+	}
+	return int32(id), nil
+}
+
+// renderSearchHistory renders the search history of a user, or a notice when
+// they turned it off
+func renderSearchHistory(c *fiber.Ctx, queries *filesdb.Queries, username string) error {
+	enabled, err := files.SearchHistoryEnabled(context.Background(), queries, username)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	history := []filesdb.SearchHistory{}
+	if enabled {
+		history, err = queries.GetSearchHistory(context.Background(), filesdb.GetSearchHistoryParams{Username: username, Limit: files.ShownSearchHistory})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+	}
+	return templates.SearchHistory(history, enabled).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// HandleRerunSearch runs a search of the history again, moving it to the top
+// of the history
+func HandleRerunSearch(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	id, err := searchHistoryId(c)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	entry, err := queries.GetSearchHistoryEntry(context.Background(), filesdb.GetSearchHistoryEntryParams{ID: id, Username: user.Username})
+	if err != nil {
+		return templates.StatusBanner(errors.New("this search is no longer in your history")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	searchEvent := agent.HistorySearchEvent(entry)
+	results, more, err := runSearch(searchEvent)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if err := recordSearchHits(user.Username, results); err != nil {
+		log.Printf("could not record the search statistics of %s: %s", user.Username, err.Error())
+	}
+	err = queries.TouchSearchHistoryEntry(context.Background(), filesdb.TouchSearchHistoryEntryParams{ID: entry.ID, Username: user.Username, ResultCount: int32(len(results))})
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	history, err := queries.GetSearchHistory(context.Background(), filesdb.GetSearchHistoryParams{Username: user.Username, Limit: files.ShownSearchHistory})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.SearchResultsWithHistory(results, nextPage(searchEvent, more), history).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// HandleDeleteSearch removes a search from the history
+func HandleDeleteSearch(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	id, err := searchHistoryId(c)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	if _, err := queries.DeleteSearchHistoryEntry(context.Background(), filesdb.DeleteSearchHistoryEntryParams{ID: id, Username: user.Username}); err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return renderSearchHistory(c, queries, user.Username)
+}
+
+// HandleClearSearchHistory removes all the searches from the history
+func HandleClearSearchHistory(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	if err := queries.ClearSearchHistory(context.Background(), user.Username); err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return renderSearchHistory(c, queries, user.Username)
+}
+
+// HandleSearchHistorySettings turns the search history on or off. Turning it
+// off also clears the history, leaving the saved searches and their alerts.
+func HandleSearchHistorySettings(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	enabled := c.FormValue("history_enabled") == "on"
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	if err := queries.SetSearchHistoryEnabled(context.Background(), filesdb.SetSearchHistoryEnabledParams{Username: user.Username, HistoryEnabled: enabled}); err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if !enabled {
+		if err := queries.ClearSearchHistory(context.Background(), user.Username); err != nil {
+			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+	}
+	return renderSearchHistory(c, queries, user.Username)
+}
+
+// HandleSaveSearch saves the search of the search form, to alert the user of
+// the new notes matching it
+func HandleSaveSearch(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	searchEvent := searchEventFromForm(c, user.Username)
+	if strings.TrimSpace(searchEvent.SearchInput) == "" {
+		return templates.StatusBanner(errors.New("please type the search to save")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	if err := files.PinSearch(context.Background(), queries, agent.NewSavedSearch(searchEvent)); err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	saved, err := queries.GetSavedSearches(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.SearchSaved(saved).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// HandleRunSavedSearch runs a saved search
+func HandleRunSavedSearch(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.StatusBanner(errors.New("invalid search id")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	saved, err := filesdb.New(db).GetSavedSearch(context.Background(), filesdb.GetSavedSearchParams{ID: int32(id), Username: user.Username})
+	if err != nil {
+		return templates.StatusBanner(errors.New("this search is no longer saved")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	searchEvent := agent.SavedSearchEvent(saved)
+	results, more, err := runSearch(searchEvent)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if err := recordSearchHits(user.Username, results); err != nil {
+		log.Printf("could not record the search statistics of %s: %s", user.Username, err.Error())
+	}
+	return templates.SearchResultsList(results, nextPage(searchEvent, more)).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// HandleDeleteSavedSearch stops alerting the user of the notes matching a
+// saved search, dropping its notifications
+func HandleDeleteSavedSearch(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.StatusBanner(errors.New("invalid search id")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	if _, err := queries.DeleteSavedSearch(context.Background(), filesdb.DeleteSavedSearchParams{ID: int32(id), Username: user.Username}); err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	saved, err := queries.GetSavedSearches(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.SavedSearches(saved).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// alertSavedSearches runs the saved searches of a user on a new note, adding
+// a notification for each of them the note matches
+func alertSavedSearches(username string, file filesdb.File) error {
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return err
+	}
+	queries := filesdb.New(db)
+	saved, err := queries.GetSavedSearches(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	for _, search := range saved {
+		searchEvent, ok := agent.NoteSearchEvent(search, file.FileName)
+		if !ok {
+			continue
+		}
+		results, _, err := runSearch(searchEvent)
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			continue
+		}
+		if err := queries.UpsertSearchAlert(context.Background(), agent.NewSearchAlert(search, file.ID, results)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordSearchHits stores which categories the results of a search came from,
+// for the category statistics. The query itself is not stored.
+func recordSearchHits(username string, results []agent.SearchResult) error {
+	hits := agent.CategoryHits(results)
+	if len(hits) == 0 {
+		return nil
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return err
+	}
+	queries := filesdb.New(db)
+	for _, hit := range hits {
+		err := queries.CreateSearchHit(context.Background(), filesdb.CreateSearchHitParams{Username: username, Category: hit.Category, Hits: hit.Hits, BestSimilarity: hit.BestSimilarity})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notesSearch runs a search reading the notes from the database
+func notesSearch(search func(context.Context, *filesdb.Queries, agent.SearchInputEvent) (*agent.SearchResponseBody, error), searchEvent agent.SearchInputEvent) (*agent.SearchResponseBody, error) {
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return nil, err
+	}
+	return search(context.Background(), filesdb.New(db), searchEvent)
+}
+
+// getTrashedNames returns the names of the trashed notes and the labels of the
+// trashed categories, leaving out the names still used by notes that are not in
+// the trash and the labels still used by such notes or by active categories
+func getTrashedNames(username string) ([]string, []string, error) {
+	filesDb, err := files.CreateNewDb()
+	if err != nil {
+		return nil, nil, err
+	}
+	filesQueries := filesdb.New(filesDb)
+	activeFiles, err := filesQueries.GetFiles(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, err
+	}
+	deletedFiles, err := filesQueries.GetDeletedFiles(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, err
+	}
+	rulesDb, err := rules.CreateNewDb()
+	if err != nil {
+		return nil, nil, err
+	}
+	rulesQueries := rulesdb.New(rulesDb)
+	deletedRules, err := rulesQueries.GetDeletedRules(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, err
+	}
+	activeRules, err := rulesQueries.GetAllRules(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, err
+	}
+	activeNames := []string{}
+	activeLabels := []string{}
+	for _, file := range activeFiles {
+		activeNames = append(activeNames, file.FileName)
+		if file.FileCategory.Valid {
+			activeLabels = append(activeLabels, rules.NormalizeLabel(file.FileCategory.String))
+		}
+	}
+	for _, rule := range activeRules {
+		activeLabels = append(activeLabels, rules.NormalizeLabel(rule.RuleType))
+	}
+	trashedFiles := []string{}
+	for _, file := range deletedFiles {
+		if !slices.Contains(activeNames, file.FileName) {
+			trashedFiles = append(trashedFiles, file.FileName)
+		}
+	}
+	trashedCategories := []string{}
+	for _, rule := range deletedRules {
+		if label := rules.NormalizeLabel(rule.RuleType); !slices.Contains(activeLabels, label) {
+			trashedCategories = append(trashedCategories, label)
+		}
+	}
+	return trashedFiles, trashedCategories, nil
+}
+
+func LoginRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	c.Set("Content-Type", "text/html")
+	return templates.SignIn().Render(c.Context(), c.Response().BodyWriter())
+}
+
+func SignUpRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	c.Set("Content-Type", "text/html")
+	return templates.SignUp().Render(c.Context(), c.Response().BodyWriter())
+}
+
+func PageDoesNotExistRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	c.Set("Content-Type", "text/html")
+	return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+}
+
+func HomeRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	_, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	return templates.Home(err == nil).Render(c.Context(), c.Response().BodyWriter())
+}
+
+func CategoriesRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := rulesdb.New(db)
+	userRules, err := queries.GetRules(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	filesDb, err := files.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	notes, err := filesdb.New(filesDb).ListFiles(context.Background(), files.ParseListOptions("", "", "", "", "", "").Params(user.Username))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	stats, err := files.LoadCategoryStats(context.Background(), filesdb.New(filesDb), user.Username, time.Now().Add(-files.StatsWindow))
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.RulesPage(user.Username, userRules, notes, stats).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// getCategoryStats returns the statistics of the categories of a user, keyed by label
+func getCategoryStats(username string) (map[string]files.CategoryStats, error) {
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return nil, err
+	}
+	return files.LoadCategoryStats(context.Background(), filesdb.New(db), username, time.Now().Add(-files.StatsWindow))
+}
+
+// LibraryRoute renders an overview of the notes library with charts of the
+// notes, FAQs and searches per category and of the weekly uploads
+func LibraryRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	userRules, err := getCategoryTree(user.Username)
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	now := time.Now()
+	stats, err := files.LoadCategoryStats(context.Background(), queries, user.Username, now.Add(-files.StatsWindow))
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	since := pgtype.Timestamp{Time: files.WeeklySeriesStart(now, files.OverviewWeeks), Valid: true}
+	uploads, err := queries.GetWeeklyUploads(context.Background(), filesdb.GetWeeklyUploadsParams{Username: user.Username, Since: since})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.LibraryPage(userRules, stats, files.WeeklySeries(uploads, now, files.OverviewWeeks)).Render(c.Context(), c.Response().BodyWriter())
+}
+
+func FilesRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	opts := files.ParseListOptions(c.Query("sort"), c.Query("order"), c.Query("category"), c.Query("type"), c.Query("tag"), c.Query("course"))
+	rulesDb, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	rulesQueries := rulesdb.New(rulesDb)
+	courses, err := rulesQueries.GetCourses(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	for _, course := range courses {
+		if course.ArchivedAt.Valid {
+			opts.ExcludedCourses = append(opts.ExcludedCourses, course.ID)
+		}
+	}
+	userRules, err := rulesQueries.GetAllRules(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if opts.Category != "" {
+		opts.Subcategories = rules.SubtreeLabels(userRules, opts.Category)
+	}
+	categories, err := queries.GetFileCategories(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	fileTypes, err := queries.GetFileTypes(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	tags, err := queries.GetTags(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	fileTags, err := queries.GetUserFileTags(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	fileList, err := queries.ListFiles(context.Background(), opts.Params(user.Username))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return templates.FilesPage([]filesdb.File{}, nil, userRules, opts, categories, fileTypes, tags, courses).Render(c.Context(), c.Response().BodyWriter())
+		}
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.FilesPage(fileList, files.GroupTagsByFile(fileTags), userRules, opts, categories, fileTypes, tags, courses).Render(c.Context(), c.Response().BodyWriter())
+}
+
+func NoteRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	fileIdInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	file, err := queries.GetFile(context.Background(), filesdb.GetFileParams{ID: int32(fileIdInt), Username: user.Username})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+		}
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	summary, faqs, edits, err := loadNoteDetails(queries, file.ID)
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	versions, err := queries.GetFileVersions(context.Background(), filesdb.GetFileVersionsParams{Username: user.Username, ID: files.VersionGroupId(file)})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	var course *rulesdb.Course
+	if file.CourseID.Valid {
+		rulesDb, err := rules.CreateNewDb()
+		if err != nil {
+			return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+		fileCourse, err := rulesdb.New(rulesDb).GetCourse(context.Background(), rulesdb.GetCourseParams{ID: file.CourseID.Int32, Username: user.Username})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+		if err == nil {
+			course = &fileCourse
+		}
+	}
+	return templates.NotePage(file, course, summary, faqs, edits, versions).Render(c.Context(), c.Response().BodyWriter())
+}
+
+func loadNoteDetails(queries *filesdb.Queries, fileId int32) (*filesdb.FileSummary, []filesdb.FileFaq, []filesdb.FileNoteEdit, error) {
+	var summary *filesdb.FileSummary
+	fileSummary, err := queries.GetFileSummary(context.Background(), fileId)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, nil, err
+		}
+	} else {
+		summary = &fileSummary
+	}
+	faqs, err := queries.GetFileFaqs(context.Background(), fileId)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, nil, err
+	}
+	edits, err := queries.GetNoteEdits(context.Background(), fileId)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, nil, err
+	}
+	return summary, faqs, edits, nil
+}
+
+// HandleUploadVersion ingests an updated file as the next version of a note.
+// The previous version is removed from the search index first, since both
+// versions may share the same name, and is put back if ingestion fails. It is
+// left in the index when another note shares its name.
+func HandleUploadVersion(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	fileIdInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	previous, err := queries.GetFile(context.Background(), filesdb.GetFileParams{ID: int32(fileIdInt), Username: user.Username})
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if previous.SupersededAt.Valid {
+		return templates.StatusBanner(errors.New("new versions can only be uploaded from the latest version of a note")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	file, err := c.FormFile("upload_file")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	src, err := file.Open()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	defer func() { _ = src.Close() }()
+	removed, err := trash.CleanupPreviousVersion(context.Background(), queries, previous)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	latest, err := ingest(user.Username, src, file.Filename)
+	if err != nil {
+		if !removed {
+			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+		notes, loadErr := files.LoadStudyNotes(context.Background(), queries, previous.ID)
+		if loadErr == nil {
+			loadErr = reindexNote(previous, notes)
+		}
+		return templates.StatusBanner(errors.Join(err, loadErr)).Render(c.Context(), c.Response().BodyWriter())
+	}
+	err = files.LinkNewVersion(context.Background(), db, previous, *latest)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	c.Set("HX-Redirect", "/notes/"+strconv.Itoa(int(latest.ID)))
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// HandleDownloadFile sends the markdown of written notes and redirects to
+// LlamaCloud for uploaded files
+func HandleDownloadFile(c *fiber.Ctx) error {
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	fileIdInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	file, err := filesdb.New(db).GetFile(context.Background(), filesdb.GetFileParams{ID: int32(fileIdInt), Username: user.Username})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+		}
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if file.Content.Valid {
+		c.Attachment(file.FileName)
+		c.Set("Content-Type", "text/markdown; charset=utf-8")
+		return c.SendString(file.Content.String)
+	}
+	if !file.LlamaCloudFileID.Valid {
+		return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+	}
+	downloadUrl, err := files.GetDownloadUrl(file.LlamaCloudFileID.String)
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return c.Redirect(downloadUrl, fiber.StatusFound)
+}
+
+// reindexNote replaces the summary and FAQs of a note in the search index
+func reindexNote(file filesdb.File, notes files.StudyNotes) error {
+	faqs := make([]agent.QuestionAndAnswer, 0, len(notes.Faqs))
+	for _, faq := range notes.Faqs {
+		faqs = append(faqs, agent.QuestionAndAnswer{Question: faq.Question, Answer: faq.Answer})
+	}
+	response, err := agent.ProcessReindex(agent.ReindexInputEvent{Username: file.Username, FileName: file.FileName, Category: file.FileCategory.String, Summary: notes.Summary, Faqs: faqs})
+	if err != nil {
+		return err
+	}
+	if response.GetErrorString() != nil {
+		return errors.New(*response.GetErrorString())
+	}
+	return nil
+}
+
+// editNote applies an edit to the summary and FAQs of the note identified by
+// the :id parameter, re-indexes them through the search backend and renders
+// the updated note editor
+func editNote(c *fiber.Ctx, description string, edit func(*filesdb.Queries, filesdb.File) (func(*filesdb.Queries) error, error)) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	fileIdInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	file, err := queries.GetFile(context.Background(), filesdb.GetFileParams{ID: int32(fileIdInt), Username: user.Username})
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if file.SupersededAt.Valid {
+		return templates.StatusBanner(errors.New("only the latest version of a note can be edited")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	apply, err := edit(queries, file)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	reindex := func(notes files.StudyNotes) error {
+		return reindexNote(file, notes)
+	}
+	err = files.EditStudyNotes(context.Background(), db, file.ID, description, apply, reindex)
+	if err != nil && !errors.Is(err, files.ErrNotReindexed) {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	// a saved edit that could not be re-indexed is shown with its warning, to be
+	// saved again or reverted from the history
+	if err != nil {
+		if err := templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter()); err != nil {
+			return err
+		}
+	}
+	summary, faqs, edits, err := loadNoteDetails(queries, file.ID)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.NoteEditor(file, summary, faqs, edits).Render(c.Context(), c.Response().BodyWriter())
+}
+
+func HandleUpdateSummary(c *fiber.Ctx) error {
+	summary := c.FormValue("summary")
+	return editNote(c, "Edited the summary", func(_ *filesdb.Queries, file filesdb.File) (func(*filesdb.Queries) error, error) {
+		if summary == "" {
+			return nil, errors.New("the summary cannot be empty")
+		}
+		return func(queries *filesdb.Queries) error {
+			return queries.UpsertFileSummary(context.Background(), filesdb.UpsertFileSummaryParams{FileID: file.ID, Summary: summary})
+		}, nil
+	})
+}
+
+func HandleCreateFaq(c *fiber.Ctx) error {
+	question := c.FormValue("question")
+	answer := c.FormValue("answer")
+	return editNote(c, "Added the question: "+question, func(_ *filesdb.Queries, file filesdb.File) (func(*filesdb.Queries) error, error) {
+		if question == "" || answer == "" {
+			return nil, errors.New("both a question and an answer are needed")
+		}
+		return func(queries *filesdb.Queries) error {
+			_, err := queries.CreateFileFaq(context.Background(), filesdb.CreateFileFaqParams{FileID: file.ID, Question: question, Answer: answer})
+			return err
+		}, nil
+	})
+}
+
+func HandleUpdateFaq(c *fiber.Ctx) error {
+	question := c.FormValue("question")
+	answer := c.FormValue("answer")
+	faqIdInt, err := strconv.Atoi(c.Params("faqId"))
+	return editNote(c, "Edited the question: "+question, func(_ *filesdb.Queries, file filesdb.File) (func(*filesdb.Queries) error, error) {
+		if err != nil {
+			return nil, err
+		}
+		if question == "" || answer == "" {
+			return nil, errors.New("both a question and an answer are needed")
+		}
+		return func(queries *filesdb.Queries) error {
+			updated, err := queries.UpdateFileFaq(context.Background(), filesdb.UpdateFileFaqParams{Question: question, Answer: answer, ID: int32(faqIdInt), FileID: file.ID})
+			if err == nil && updated == 0 {
+				return errors.New("this question does not exist")
+			}
+			return err
+		}, nil
+	})
+}
+
+func HandleDeleteFaq(c *fiber.Ctx) error {
+	faqIdInt, err := strconv.Atoi(c.Params("faqId"))
+	return editNote(c, "Deleted a question", func(_ *filesdb.Queries, file filesdb.File) (func(*filesdb.Queries) error, error) {
+		if err != nil {
+			return nil, err
+		}
+		return func(queries *filesdb.Queries) error {
+			deleted, err := queries.DeleteFileFaq(context.Background(), filesdb.DeleteFileFaqParams{ID: int32(faqIdInt), FileID: file.ID})
+			if err == nil && deleted == 0 {
+				return errors.New("this question does not exist")
+			}
+			return err
+		}, nil
+	})
+}
+
+func HandleRevertNoteEdit(c *fiber.Ctx) error {
+	editIdInt, err := strconv.Atoi(c.Params("editId"))
+	return editNote(c, "Reverted an edit", func(queries *filesdb.Queries, file filesdb.File) (func(*filesdb.Queries) error, error) {
+		if err != nil {
+			return nil, err
+		}
+		entry, err := queries.GetNoteEdit(context.Background(), filesdb.GetNoteEditParams{ID: int32(editIdInt), FileID: file.ID})
+		if err != nil {
+			return nil, err
+		}
+		return files.RevertStudyNotes(context.Background(), file.ID, entry)
+	})
+}
+
+func TrashRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	deletedFiles, deletedRules, err := getTrash(user.Username)
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.TrashPage(deletedFiles, deletedRules, int(trash.Retention().Hours()/24)).Render(c.Context(), c.Response().BodyWriter())
+}
+
+func getTrash(username string) ([]filesdb.File, []rulesdb.Rule, error) {
+	filesDb, err := files.CreateNewDb()
+	if err != nil {
+		return nil, nil, err
+	}
+	deletedFiles, err := filesdb.New(filesDb).GetDeletedFiles(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, err
+	}
+	rulesDb, err := rules.CreateNewDb()
+	if err != nil {
+		return nil, nil, err
+	}
+	deletedRules, err := rulesdb.New(rulesDb).GetDeletedRules(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, err
+	}
+	return deletedFiles, deletedRules, nil
+}
+
+// handleTrashAction runs an action on a trashed note or category identified by
+// the :id parameter and renders the updated trash
+func handleTrashAction(c *fiber.Ctx, action func(username string, id int32) (int64, error)) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	idInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	affected, err := action(user.Username, int32(idInt))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if affected == 0 {
+		return templates.StatusBanner(errors.New("this item is not in the trash")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	deletedFiles, deletedRules, err := getTrash(user.Username)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.TrashList(deletedFiles, deletedRules).Render(c.Context(), c.Response().BodyWriter())
+}
+
+func HandleRestoreFile(c *fiber.Ctx) error {
+	return handleTrashAction(c, func(username string, id int32) (int64, error) {
+		db, err := files.CreateNewDb()
+		if err != nil {
+			return 0, err
+		}
+		return filesdb.New(db).RestoreFile(context.Background(), filesdb.RestoreFileParams{ID: id, Username: username})
+	})
+}
+
+func HandlePurgeFile(c *fiber.Ctx) error {
+	return handleTrashAction(c, func(username string, id int32) (int64, error) {
+		db, err := files.CreateNewDb()
+		if err != nil {
+			return 0, err
+		}
+		return trash.PurgeFile(context.Background(), filesdb.New(db), username, id)
+	})
+}
+
+func HandleRestoreRule(c *fiber.Ctx) error {
+	return handleTrashAction(c, func(username string, id int32) (int64, error) {
+		db, err := rules.CreateNewDb()
+		if err != nil {
+			return 0, err
+		}
+		restored, err := rulesdb.New(db).RestoreRule(context.Background(), rulesdb.RestoreRuleParams{ID: id, Username: username})
+		if conflict := rules.RuleConflict(err); conflict != nil {
+			return 0, fmt.Errorf("%w, rename it before restoring this one", conflict)
+		}
+		return restored, err
+	})
+}
+
+func HandlePurgeRule(c *fiber.Ctx) error {
+	return handleTrashAction(c, func(username string, id int32) (int64, error) {
+		db, err := rules.CreateNewDb()
+		if err != nil {
+			return 0, err
+		}
+		return rulesdb.New(db).DeleteRule(context.Background(), rulesdb.DeleteRuleParams{ID: id, Username: username})
+	})
+}
+
+func SearchRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := rulesdb.New(db)
+	rules, err := queries.GetRules(context.Background(), user.Username)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+		rules = []rulesdb.Rule{}
+	}
+	dbFiles, err := files.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queriesFiles := filesdb.New(dbFiles)
+	historyEnabled, err := files.SearchHistoryEnabled(context.Background(), queriesFiles, user.Username)
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	history := []filesdb.SearchHistory{}
+	if historyEnabled {
+		history, err = queriesFiles.GetSearchHistory(context.Background(), filesdb.GetSearchHistoryParams{Username: user.Username, Limit: files.ShownSearchHistory})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+	}
+	saved, err := queriesFiles.GetSavedSearches(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	files, err := queriesFiles.GetFiles(context.Background(), user.Username)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+		files = []filesdb.File{}
+	}
+	tags, err := queriesFiles.GetTags(context.Background(), user.Username)
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	courses, err := queries.GetCourses(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	selectedCourse, _ := strconv.Atoi(c.Query("course"))
+	return templates.SearchPage(rules, files, tags, courses, int32(selectedCourse), history, historyEnabled, saved).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// NotificationsRoute lists the notes that newly matched the saved searches of
+// a user, marking them as read
+func NotificationsRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	alerts, err := queries.GetSearchAlerts(context.Background(), filesdb.GetSearchAlertsParams{Username: user.Username, Limit: files.ShownSearchAlerts})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if err := queries.MarkSearchAlertsRead(context.Background(), user.Username); err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.NotificationsPage(alerts).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// NotificationsCountRoute renders the number of unread notifications shown in
+// the navigation bar, or nothing for signed out visitors
+func NotificationsCountRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	c.Set("Content-Type", "text/html")
+	user, err := auth.AuthorizeGet(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	count, err := filesdb.New(db).CountUnreadSearchAlerts(context.Background(), user.Username)
+	if err != nil {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	return templates.NotificationsBadge(count).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// renderNotifications renders the list of notifications of a user
+func renderNotifications(c *fiber.Ctx, queries *filesdb.Queries, username string) error {
+	alerts, err := queries.GetSearchAlerts(context.Background(), filesdb.GetSearchAlertsParams{Username: username, Limit: files.ShownSearchAlerts})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.Notifications(alerts).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// HandleDeleteNotification dismisses a notification
+func HandleDeleteNotification(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.StatusBanner(errors.New("invalid notification id")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	if _, err := queries.DeleteSearchAlert(context.Background(), filesdb.DeleteSearchAlertParams{ID: int32(id), Username: user.Username}); err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return renderNotifications(c, queries, user.Username)
+}
+
+// HandleClearNotifications dismisses all the notifications of a user
+func HandleClearNotifications(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := filesdb.New(db)
+	if err := queries.ClearSearchAlerts(context.Background(), user.Username); err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return renderNotifications(c, queries, user.Username)
+}
+
+func CoursesRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	courses, err := rulesdb.New(db).GetCourses(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	active := []rulesdb.Course{}
+	archived := []rulesdb.Course{}
+	for _, course := range courses {
+		if course.ArchivedAt.Valid {
+			archived = append(archived, course)
+		} else {
+			active = append(active, course)
+		}
+	}
+	return templates.CoursesPage(rules.GroupBySemester(active), archived).Render(c.Context(), c.Response().BodyWriter())
+}
+
+func CourseRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	courseIdInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := rulesdb.New(db)
+	course, err := queries.GetCourse(context.Background(), rulesdb.GetCourseParams{ID: int32(courseIdInt), Username: user.Username})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+		}
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	courseRules, err := queries.GetCourseRules(context.Background(), rulesdb.GetCourseRulesParams{Username: user.Username, CourseID: course.ID})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	otherRules := []rulesdb.Rule{}
+	userRules, err := queries.GetRules(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	for _, rule := range userRules {
+		if !rule.CourseID.Valid || rule.CourseID.Int32 != course.ID {
+			otherRules = append(otherRules, rule)
+		}
+	}
+	filesDb, err := files.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	filesQueries := filesdb.New(filesDb)
+	opts := files.ParseListOptions("name", "asc", "", "", "", c.Params("id"))
+	courseFiles, err := filesQueries.ListFiles(context.Background(), opts.Params(user.Username))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	fileTags, err := filesQueries.GetUserFileTags(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.CoursePage(course, courseRules, otherRules, courseFiles, files.GroupTagsByFile(fileTags)).Render(c.Context(), c.Response().BodyWriter())
+}
+
+func HandleCreateCourse(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	name, semester, err := rules.ParseCourse(c.FormValue("name"), c.FormValue("semester"))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	course, err := rulesdb.New(db).CreateCourse(context.Background(), rulesdb.CreateCourseParams{Username: user.Username, Name: name, Semester: semester})
+	if err != nil {
+		if rules.IsUniqueViolation(err) {
+			return templates.StatusBanner(errors.New("a course with this name already exists for this semester")).Render(c.Context(), c.Response().BodyWriter())
+		}
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	c.Set("HX-Redirect", "/courses/"+strconv.Itoa(int(course.ID)))
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+// handleCourseAction runs an action on the course identified by the :id
+// parameter and reloads the course page
+func handleCourseAction(c *fiber.Ctx, redirectUrl string, action func(queries *rulesdb.Queries, username string, id int32) (int64, error)) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	courseIdInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	affected, err := action(rulesdb.New(db), user.Username, int32(courseIdInt))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if affected == 0 {
+		return templates.StatusBanner(errors.New("this course does not exist")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if redirectUrl == "" {
+		redirectUrl = "/courses/" + c.Params("id")
+	}
+	c.Set("HX-Redirect", redirectUrl)
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func HandleArchiveCourse(c *fiber.Ctx) error {
+	return handleCourseAction(c, "", func(queries *rulesdb.Queries, username string, id int32) (int64, error) {
+		return queries.ArchiveCourse(context.Background(), rulesdb.ArchiveCourseParams{ID: id, Username: username})
+	})
+}
+
+func HandleUnarchiveCourse(c *fiber.Ctx) error {
+	return handleCourseAction(c, "", func(queries *rulesdb.Queries, username string, id int32) (int64, error) {
+		return queries.UnarchiveCourse(context.Background(), rulesdb.UnarchiveCourseParams{ID: id, Username: username})
+	})
+}
+
+// HandleDeleteCourse removes a course, leaving its categories and notes ungrouped
+func HandleDeleteCourse(c *fiber.Ctx) error {
+	return handleCourseAction(c, "/courses", func(queries *rulesdb.Queries, username string, id int32) (int64, error) {
+		deleted, err := queries.DeleteCourse(context.Background(), rulesdb.DeleteCourseParams{ID: id, Username: username})
+		if err != nil || deleted == 0 {
+			return deleted, err
+		}
+		db, err := files.CreateNewDb()
+		if err != nil {
+			return 0, err
+		}
+		return deleted, filesdb.New(db).ClearFilesCourse(context.Background(), filesdb.ClearFilesCourseParams{Username: username, CourseID: id})
+	})
+}
+
+// setRuleCourse moves a category, along with the notes classified in it, to a
+// course, or out of any course when courseId is not valid
+func setRuleCourse(username string, ruleId int32, courseId pgtype.Int4) error {
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return err
+	}
+	rule, err := rulesdb.New(db).SetRuleCourse(context.Background(), rulesdb.SetRuleCourseParams{CourseID: courseId, ID: ruleId, Username: username})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("this category does not exist")
+		}
+		return err
+	}
+	filesDb, err := files.CreateNewDb()
+	if err != nil {
+		return err
+	}
+	return filesdb.New(filesDb).SetCategoryCourse(context.Background(), filesdb.SetCategoryCourseParams{CourseID: courseId, Username: username, FileCategory: rules.NormalizeLabel(rule.RuleType)})
+}
+
+func HandleAddCourseCategory(c *fiber.Ctx) error {
+	return handleCourseAction(c, "", func(queries *rulesdb.Queries, username string, id int32) (int64, error) {
+		ruleIdInt, err := strconv.Atoi(c.FormValue("rule_id"))
+		if err != nil {
+			return 0, errors.New("select a category to add to the course")
+		}
+		_, err = queries.GetCourse(context.Background(), rulesdb.GetCourseParams{ID: id, Username: username})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		return 1, setRuleCourse(username, int32(ruleIdInt), pgtype.Int4{Int32: id, Valid: true})
+	})
+}
+
+func HandleRemoveCourseCategory(c *fiber.Ctx) error {
+	return handleCourseAction(c, "", func(queries *rulesdb.Queries, username string, id int32) (int64, error) {
+		ruleIdInt, err := strconv.Atoi(c.Params("ruleId"))
+		if err != nil {
+			return 0, err
+		}
+		_, err = queries.GetCourse(context.Background(), rulesdb.GetCourseParams{ID: id, Username: username})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		rule, err := queries.GetRule(context.Background(), rulesdb.GetRuleParams{ID: int32(ruleIdInt), Username: username})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return 0, err
+		}
+		if err != nil || !rule.CourseID.Valid || rule.CourseID.Int32 != id {
+			return 0, errors.New("this category is not part of the course")
+		}
+		return 1, setRuleCourse(username, rule.ID, pgtype.Int4{})
+	})
+}
+
+// loadPresets merges the presets shipped with the binary with the ones
+// published by admins
+func loadPresets(queries *rulesdb.Queries) ([]rules.Preset, error) {
+	embedded, err := rules.EmbeddedPresets()
+	if err != nil {
+		return nil, err
+	}
+	published, err := queries.GetPublishedPresets(context.Background())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	publishedPresets := make([]rules.Preset, 0, len(published))
+	for _, preset := range published {
+		publishedPresets = append(publishedPresets, rules.PresetFromPublished(preset))
+	}
+	return rules.MergePresets(embedded, publishedPresets), nil
+}
+
+func PresetsRoute(c *fiber.Ctx) error {
+	if c.Method() != fiber.MethodGet {
+		return c.SendStatus(fiber.StatusMethodNotAllowed)
+	}
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := rulesdb.New(db)
+	presets, err := loadPresets(queries)
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	presetRules, err := queries.GetPresetRules(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.PresetsPage(rules.GroupPresets(presets), rules.ImportedVersions(presetRules), auth.IsAdmin(user.Username)).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// HandleImportPresets creates a category for each selected preset. Presets
+// that were already imported get their description updated to the latest
+// version instead.
+func HandleImportPresets(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	keys := c.Request().PostArgs().PeekMulti("preset")
+	if len(keys) == 0 {
+		return templates.StatusBanner(errors.New("select at least one preset to import")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := rulesdb.New(db)
+	presets, err := loadPresets(queries)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	presetRules, err := queries.GetPresetRules(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	skipped := []string{}
+	for _, key := range keys {
+		preset, ok := rules.FindPreset(presets, string(key))
+		if !ok {
+			continue
+		}
+		version := pgtype.Int4{Int32: preset.Version, Valid: true}
+		idx := slices.IndexFunc(presetRules, func(rule rulesdb.Rule) bool { return rule.PresetKey.String == preset.Key })
+		if idx >= 0 {
+			if presetRules[idx].PresetVersion.Int32 >= preset.Version {
+				continue
+			}
+			_, err = queries.UpdatePresetRule(context.Background(), rulesdb.UpdatePresetRuleParams{RuleDescription: preset.Description, PresetVersion: version, ID: presetRules[idx].ID, Username: user.Username})
+		} else {
+			_, err = queries.CreatePresetRule(context.Background(), rulesdb.CreatePresetRuleParams{Username: user.Username, RuleName: preset.Name, RuleType: preset.Label, RuleDescription: preset.Description, PresetKey: pgtype.Text{String: preset.Key, Valid: true}, PresetVersion: version})
+		}
+		if err != nil {
+			if rules.RuleConflict(err) == nil {
+				return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+			}
+			skipped = append(skipped, preset.Name)
+		}
+	}
+	if len(skipped) > 0 {
+		return templates.StatusBanner(fmt.Errorf("you already have categories with the same name or label as: %s. Rename them to import these presets", strings.Join(skipped, ", "))).Render(c.Context(), c.Response().BodyWriter())
+	}
+	c.Set("HX-Redirect", "/categories")
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// HandlePublishPreset lets admins publish a new preset, or a new version of an
+// existing one, without redeploying
+func HandlePublishPreset(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if !auth.IsAdmin(user.Username) {
+		return templates.StatusBanner(auth.ErrUnauthorized).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	queries := rulesdb.New(db)
+	presets, err := loadPresets(queries)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	preset := rules.Preset{
+		Key:         strings.TrimSpace(c.FormValue("key")),
+		Name:        strings.TrimSpace(c.FormValue("name")),
+		Label:       rules.NormalizeLabel(c.FormValue("label")),
+		Group:       strings.TrimSpace(c.FormValue("group")),
+		Description: strings.TrimSpace(c.FormValue("description")),
+	}
+	preset.Version = rules.NextPresetVersion(presets, preset.Key)
+	if err := rules.ValidatePreset(preset); err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	_, err = queries.PublishPreset(context.Background(), rulesdb.PublishPresetParams{PresetKey: preset.Key, Name: preset.Name, Label: preset.Label, GroupName: preset.Group, Description: preset.Description, Version: preset.Version, PublishedBy: user.Username})
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	c.Set("HX-Redirect", "/categories/presets")
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// HandleUnpublishPreset removes a published preset. Presets shipped with the
+// binary fall back to their embedded version.
+func HandleUnpublishPreset(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if !auth.IsAdmin(user.Username) {
+		return templates.StatusBanner(auth.ErrUnauthorized).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	deleted, err := rulesdb.New(db).DeletePublishedPreset(context.Background(), c.Params("key"))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if deleted == 0 {
+		return templates.StatusBanner(errors.New("this preset was not published")).Render(c.Context(), c.Response().BodyWriter())
+	}
+	c.Set("HX-Redirect", "/categories/presets")
+	return c.SendStatus(fiber.StatusOK)
+}
+
+const maxImportDocumentSize = 1 << 20
+
+// HandleExportRules downloads the categories of the user, including the ones
+// of archived courses, as a JSON or YAML document
+func HandleExportRules(c *fiber.Ctx) error {
+	user, err := auth.AuthorizeGet(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.AuthFailedPage().Render(c.Context(), c.Response().BodyWriter())
+	}
+	format := c.Query("format", "json")
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	userRules, err := rulesdb.New(db).GetAllRules(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.Page500(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	document, err := rules.MarshalExport(rules.NewExport(userRules), format)
+	if err != nil {
+		return templates.Page404().Render(c.Context(), c.Response().BodyWriter())
+	}
+	c.Attachment("categories." + format)
+	if format == "yaml" {
+		c.Set("Content-Type", "application/yaml; charset=utf-8")
+	} else {
+		c.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	return c.Send(document)
+}
+
+// readImportDocument reads the document to import, either uploaded as a file
+// or pasted in the form
+func readImportDocument(c *fiber.Ctx) (string, error) {
+	file, err := c.FormFile("upload_file")
+	if err != nil {
+		return c.FormValue("document"), nil
+	}
+	if file.Size > maxImportDocumentSize {
+		return "", errors.New("the document is limited to 1MB")
+	}
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = src.Close() }()
+	data, err := io.ReadAll(src)
+	return string(data), err
+}
+
+// planRulesImport parses the import document and matches it against the
+// categories of the user
+func planRulesImport(username string, document string) ([]rules.ImportItem, error) {
+	if len(document) > maxImportDocumentSize {
+		return nil, errors.New("the document is limited to 1MB")
+	}
+	export, err := rules.ParseExport([]byte(document))
+	if err != nil {
+		return nil, err
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return nil, err
+	}
+	userRules, err := rulesdb.New(db).GetAllRules(context.Background(), username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return rules.PlanImport(export, userRules), nil
+}
+
+// HandlePreviewRulesImport shows which categories an import would add, update
+// or skip because of a conflict, without changing anything
+func HandlePreviewRulesImport(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	document, err := readImportDocument(c)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	plan, err := planRulesImport(user.Username, document)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	return templates.RulesImportPreview(plan, rules.CountImportActions(plan), document).Render(c.Context(), c.Response().BodyWriter())
+}
+
+// HandleImportRules applies an import previewed by HandlePreviewRulesImport.
+// The plan is computed again, so that changes made in between are accounted for.
+func HandleImportRules(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	plan, err := planRulesImport(user.Username, c.FormValue("document"))
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	// the notes of the updated categories move to their new labels in the
+	// transaction of the import, and are re-indexed once it is committed
+	var renamed []filesdb.File
+	err = rules.ApplyImport(context.Background(), db, user.Username, plan, func(tx pgx.Tx, previousLabel string, label string) error {
+		relabeled, err := relabelNotes(context.Background(), filesdb.New(tx), user.Username, previousLabel, label)
+		renamed = append(renamed, relabeled...)
+		return err
+	})
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if len(renamed) > 0 {
+		filesDb, err := files.CreateNewDb()
+		if err != nil {
+			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+		if failed := reindexRenamed(filesdb.New(filesDb), renamed); failed > 0 {
+			return templates.StatusBanner(fmt.Errorf("the categories were imported, but %d of their notes could not be re-indexed", failed)).Render(c.Context(), c.Response().BodyWriter())
+		}
+	}
+	c.Set("HX-Redirect", "/categories")
+	return c.SendStatus(fiber.StatusOK)
+}
+
+const (
+	maxSampleTextLength = 20000
+	// categoryTestSampleName is the name of a pasted sample uploaded to LlamaCloud
+	categoryTestSampleName = "category-test-sample.txt"
+)
+
+// HandleTestRules classifies a sample note, pasted or picked among the uploaded
+// ones, against the saved categories and an optional draft category, without
+// storing anything. Pasted samples are uploaded to LlamaCloud for the time of
+// the test only.
+func HandleTestRules(c *fiber.Ctx) error {
+	user, err := auth.AuthorizePost(c)
+	c.Set("Content-Type", "text/html")
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	draft := rules.DraftRule{Name: c.FormValue("rule_name"), Label: c.FormValue("rule_type"), Description: c.FormValue("rule_description")}
+	if replacedId, err := strconv.Atoi(c.FormValue("replace_rule_id")); err == nil {
+		draft.ReplacedID = int32(replacedId)
+	}
+	draft.ParentID = formParentId(c)
+	db, err := rules.CreateNewDb()
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	// like the classification of uploads, the categories of archived courses are left out
+	userRules, err := rulesdb.New(db).GetClassificationRules(context.Background(), user.Username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	ruleSet, err := rules.DraftRuleSet(userRules, draft)
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+
+	var fileId string
+	if noteId, err := strconv.Atoi(c.FormValue("file_id")); err == nil {
+		filesDb, err := files.CreateNewDb()
+		if err != nil {
+			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+		note, err := filesdb.New(filesDb).GetFile(context.Background(), filesdb.GetFileParams{ID: int32(noteId), Username: user.Username})
+		if err != nil {
+			return templates.StatusBanner(errors.New("this note does not exist")).Render(c.Context(), c.Response().BodyWriter())
+		}
+		if !note.LlamaCloudFileID.Valid {
+			return templates.StatusBanner(errors.New("this note cannot be classified again, paste its content instead")).Render(c.Context(), c.Response().BodyWriter())
+		}
+		fileId = note.LlamaCloudFileID.String
+	} else {
+		sample := strings.TrimSpace(c.FormValue("sample_text"))
+		if sample == "" {
+			return templates.StatusBanner(errors.New("paste a sample note or pick one of your notes")).Render(c.Context(), c.Response().BodyWriter())
+		}
+		if len([]rune(sample)) > maxSampleTextLength {
+			return templates.StatusBanner(fmt.Errorf("the sample is limited to %d characters", maxSampleTextLength)).Render(c.Context(), c.Response().BodyWriter())
+		}
+		uploaded, err := files.UploadFile(strings.NewReader(sample), categoryTestSampleName)
+		if err != nil {
+			return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+		}
+		defer func() {
+			if err := trash.CleanupUpload(context.Background(), user.Username, categoryTestSampleName, uploaded.ID); err != nil {
+				log.Printf("could not record the cleanup of the category test sample of %s: %s", user.Username, err.Error())
+			}
+		}()
+		fileId = uploaded.ID
+	}
+
+	classifyRules := make([]agent.ClassifyRule, 0, len(ruleSet))
+	for _, rule := range ruleSet {
+		classifyRule := agent.ClassifyRule{RuleType: rules.NormalizeLabel(rule.RuleType), RuleDescription: rule.RuleDescription}
+		// subcategories are classified against once their parent was chosen
+		if rule.ID != 0 {
+			id := rule.ID
+			classifyRule.ID = &id
+		}
+		if parentId := rules.ParentOf(ruleSet, rule); parentId != 0 {
+			classifyRule.ParentID = &parentId
+		}
+		classifyRules = append(classifyRules, classifyRule)
+	}
+	response, err := agent.ProcessClassify(agent.ClassifyInputEvent{FileId: fileId, Rules: classifyRules})
+	if err != nil {
+		return templates.StatusBanner(err).Render(c.Context(), c.Response().BodyWriter())
+	}
+	if response.GetErrorString() != nil {
+		return templates.StatusBanner(errors.New(*response.GetErrorString())).Render(c.Context(), c.Response().BodyWriter())
+	}
+	classification := response.GetClassification()
+	if classification.Category == nil {
+		return templates.StatusBanner(errors.New("no category was picked for this sample")).Render(c.Context(), c.Response().BodyWriter())
 	}
-	return templates.SearchPage(rules, files).Render(c.Context(), c.Response().BodyWriter())
+	matched, _ := rules.FindRuleByLabel(ruleSet, *classification.Category)
+	isDraft := !draft.IsEmpty() && rules.NormalizeLabel(matched.RuleType) == rules.NormalizeLabel(draft.Label)
+	return templates.RuleTestResult(classification, matched, isDraft).Render(c.Context(), c.Response().BodyWriter())
 }
@@ -16,12 +16,16 @@ import (
 	"github.com/gofiber/storage/sqlite3"
 	"github.com/run-llama/study-llama/frontend/auth"
 	"github.com/run-llama/study-llama/frontend/handlers"
+	"github.com/run-llama/study-llama/frontend/trash"
 )
 
 func main() {
 	// Create a new Fiber app
 	app := Setup()
 
+	// Permanently delete notes and categories past the trash retention window
+	go trash.SchedulePurge(1 * time.Hour)
+
 	// Start the Fiber server on port 8000
 	if err := app.Listen(":8000"); err != nil {
 		log.Fatalf("Error starting server: %v", err)
@@ -120,14 +124,62 @@ func Setup() *fiber.App {
 	app.Get("/signin", defaultCache, corsSetup("GET"), handlers.LoginRoute)
 	app.Get("/signup", defaultCache, corsSetup("GET"), handlers.SignUpRoute)
 	app.Get("/categories", corsSetup("GET"), handlers.CategoriesRoute)
+	app.Get("/categories/presets", corsSetup("GET"), handlers.PresetsRoute)
+	app.Post("/categories/presets", limiterSetup(10), corsSetup("POST"), handlers.HandlePublishPreset)
+	app.Post("/categories/presets/import", limiterSetup(10), corsSetup("POST"), handlers.HandleImportPresets)
+	app.Delete("/categories/presets/:key", limiterSetup(10), corsSetup("DELETE"), handlers.HandleUnpublishPreset)
+	app.Get("/categories/export", corsSetup("GET"), handlers.HandleExportRules)
+	app.Post("/categories/import/preview", limiterSetup(10), corsSetup("POST"), handlers.HandlePreviewRulesImport)
+	app.Post("/categories/import", limiterSetup(10), corsSetup("POST"), handlers.HandleImportRules)
+	app.Post("/categories/test", limiterSetup(10), corsSetup("POST"), handlers.HandleTestRules)
 	app.Post("/rules", limiterSetup(10), corsSetup("POST"), handlers.HandleCreateRule)
-	app.Patch("/rules", limiterSetup(10), corsSetup("POST"), handlers.HandleUpdateRule)
+	app.Patch("/rules/:id", limiterSetup(10), corsSetup("PATCH"), handlers.HandleUpdateRule)
 	app.Delete("/rules/:id", limiterSetup(10), corsSetup("DELETE"), handlers.HandleDeleteRule)
+	app.Get("/library", corsSetup("GET"), handlers.LibraryRoute)
 	app.Get("/notes", corsSetup("GET"), handlers.FilesRoute)
 	app.Post("/notes", limiterSetup(10), corsSetup("POST"), handlers.HandleUploadFile)
+	app.Post("/notes/import", limiterSetup(10), corsSetup("POST"), handlers.HandleImportUrl)
+	app.Get("/notes/new", corsSetup("GET"), handlers.NoteWriterRoute)
+	app.Post("/notes/new", limiterSetup(10), corsSetup("POST"), handlers.HandleCreateNote)
+	app.Post("/notes/drafts", limiterSetup(60), corsSetup("POST"), handlers.HandleSaveDraft)
+	app.Delete("/notes/drafts/:id", limiterSetup(10), corsSetup("DELETE"), handlers.HandleDeleteDraft)
+	app.Post("/notes/preview", limiterSetup(60), corsSetup("POST"), handlers.HandlePreviewNote)
+	app.Get("/notes/:id", corsSetup("GET"), handlers.NoteRoute)
+	app.Get("/notes/:id/download", corsSetup("GET"), handlers.HandleDownloadFile)
+	app.Post("/notes/:id/versions", limiterSetup(10), corsSetup("POST"), handlers.HandleUploadVersion)
+	app.Patch("/notes/:id/summary", limiterSetup(10), corsSetup("PATCH"), handlers.HandleUpdateSummary)
+	app.Patch("/notes/:id/tags", limiterSetup(10), corsSetup("PATCH"), handlers.HandleUpdateTags)
+	app.Post("/notes/:id/faqs", limiterSetup(10), corsSetup("POST"), handlers.HandleCreateFaq)
+	app.Patch("/notes/:id/faqs/:faqId", limiterSetup(10), corsSetup("PATCH"), handlers.HandleUpdateFaq)
+	app.Delete("/notes/:id/faqs/:faqId", limiterSetup(10), corsSetup("DELETE"), handlers.HandleDeleteFaq)
+	app.Post("/notes/:id/history/:editId/revert", limiterSetup(10), corsSetup("POST"), handlers.HandleRevertNoteEdit)
 	app.Delete("/notes/:id", limiterSetup(10), corsSetup("DELETE"), handlers.HandleDeleteFile)
+	app.Get("/trash", corsSetup("GET"), handlers.TrashRoute)
+	app.Post("/trash/notes/:id/restore", limiterSetup(10), corsSetup("POST"), handlers.HandleRestoreFile)
+	app.Delete("/trash/notes/:id", limiterSetup(10), corsSetup("DELETE"), handlers.HandlePurgeFile)
+	app.Post("/trash/rules/:id/restore", limiterSetup(10), corsSetup("POST"), handlers.HandleRestoreRule)
+	app.Delete("/trash/rules/:id", limiterSetup(10), corsSetup("DELETE"), handlers.HandlePurgeRule)
+	app.Get("/courses", corsSetup("GET"), handlers.CoursesRoute)
+	app.Post("/courses", limiterSetup(10), corsSetup("POST"), handlers.HandleCreateCourse)
+	app.Get("/courses/:id", corsSetup("GET"), handlers.CourseRoute)
+	app.Post("/courses/:id/archive", limiterSetup(10), corsSetup("POST"), handlers.HandleArchiveCourse)
+	app.Post("/courses/:id/unarchive", limiterSetup(10), corsSetup("POST"), handlers.HandleUnarchiveCourse)
+	app.Delete("/courses/:id", limiterSetup(10), corsSetup("DELETE"), handlers.HandleDeleteCourse)
+	app.Post("/courses/:id/categories", limiterSetup(10), corsSetup("POST"), handlers.HandleAddCourseCategory)
+	app.Delete("/courses/:id/categories/:ruleId", limiterSetup(10), corsSetup("DELETE"), handlers.HandleRemoveCourseCategory)
 	app.Get("/review", corsSetup("GET"), handlers.SearchRoute)
 	app.Post("/review", limiterSetup(10), corsSetup("POST"), handlers.HandleSearch)
+	app.Post("/review/history/settings", limiterSetup(10), corsSetup("POST"), handlers.HandleSearchHistorySettings)
+	app.Post("/review/history/:id/run", limiterSetup(10), corsSetup("POST"), handlers.HandleRerunSearch)
+	app.Delete("/review/history/:id", limiterSetup(10), corsSetup("DELETE"), handlers.HandleDeleteSearch)
+	app.Delete("/review/history", limiterSetup(10), corsSetup("DELETE"), handlers.HandleClearSearchHistory)
+	app.Post("/review/saved", limiterSetup(10), corsSetup("POST"), handlers.HandleSaveSearch)
+	app.Post("/review/saved/:id/run", limiterSetup(10), corsSetup("POST"), handlers.HandleRunSavedSearch)
+	app.Delete("/review/saved/:id", limiterSetup(10), corsSetup("DELETE"), handlers.HandleDeleteSavedSearch)
+	app.Get("/notifications", corsSetup("GET"), handlers.NotificationsRoute)
+	app.Get("/notifications/count", corsSetup("GET"), handlers.NotificationsCountRoute)
+	app.Delete("/notifications/:id", limiterSetup(10), corsSetup("DELETE"), handlers.HandleDeleteNotification)
+	app.Delete("/notifications", limiterSetup(10), corsSetup("DELETE"), handlers.HandleClearNotifications)
 	app.Get("/", handlers.HomeRoute)
 	app.Static("/static", "./static/")
 	app.Use(handlers.PageDoesNotExistRoute)
@@ -1,7 +1,466 @@
 -- name: GetFiles :many
 SELECT * FROM files
-WHERE username = $1;
+WHERE username = $1 AND deleted_at IS NULL AND superseded_at IS NULL;
+
+-- name: ListFiles :many
+SELECT * FROM files
+WHERE username = sqlc.arg(username)
+  AND deleted_at IS NULL
+  AND superseded_at IS NULL
+  AND (sqlc.narg(file_category)::text IS NULL OR file_category = sqlc.narg(file_category) OR file_category = ANY(sqlc.arg(subcategories)::text[]))
+  AND (sqlc.narg(mime_type)::text IS NULL OR mime_type = sqlc.narg(mime_type))
+  AND (sqlc.narg(tag)::text IS NULL OR EXISTS (
+    SELECT 1 FROM file_tags
+    JOIN tags ON tags.id = file_tags.tag_id
+    WHERE file_tags.file_id = files.id AND tags.name = sqlc.narg(tag)
+  ))
+  AND (sqlc.narg(course_id)::int IS NULL OR course_id = sqlc.narg(course_id))
+  AND (course_id IS NULL OR NOT course_id = ANY(sqlc.arg(excluded_courses)::int[]))
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by)::text = 'date' AND NOT sqlc.arg(sort_desc)::boolean THEN uploaded_at END ASC NULLS LAST,
+  CASE WHEN sqlc.arg(sort_by)::text = 'date' AND sqlc.arg(sort_desc)::boolean THEN uploaded_at END DESC NULLS LAST,
+  CASE WHEN sqlc.arg(sort_by)::text = 'size' AND NOT sqlc.arg(sort_desc)::boolean THEN file_size END ASC NULLS LAST,
+  CASE WHEN sqlc.arg(sort_by)::text = 'size' AND sqlc.arg(sort_desc)::boolean THEN file_size END DESC NULLS LAST,
+  CASE WHEN sqlc.arg(sort_by)::text = 'name' AND NOT sqlc.arg(sort_desc)::boolean THEN file_name END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_desc)::boolean THEN file_name END DESC,
+  id ASC;
+
+-- name: GetFileCategories :many
+SELECT DISTINCT file_category FROM files
+WHERE username = $1 AND file_category IS NOT NULL AND deleted_at IS NULL AND superseded_at IS NULL
+ORDER BY file_category;
+
+-- name: GetFileTypes :many
+SELECT DISTINCT mime_type FROM files
+WHERE username = $1 AND mime_type IS NOT NULL AND deleted_at IS NULL AND superseded_at IS NULL
+ORDER BY mime_type;
+
+-- name: GetFile :one
+SELECT * FROM files
+WHERE id = $1 AND username = $2 AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: UpdateFileMetadata :one
+UPDATE files
+SET uploaded_at = COALESCE(sqlc.narg(uploaded_at), uploaded_at),
+    file_size = sqlc.narg(file_size),
+    page_count = sqlc.narg(page_count),
+    mime_type = sqlc.narg(mime_type)
+WHERE username = sqlc.arg(username) AND llama_cloud_file_id = sqlc.arg(llama_cloud_file_id)
+RETURNING *;
+
+-- name: GetFileSummary :one
+SELECT * FROM file_summaries
+WHERE file_id = $1
+LIMIT 1;
+
+-- name: UpsertFileSummary :exec
+INSERT INTO file_summaries (
+  file_id, summary
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (file_id) DO UPDATE
+SET summary = EXCLUDED.summary;
+
+-- name: GetFileFaqs :many
+SELECT * FROM file_faqs
+WHERE file_id = $1
+ORDER BY id;
+
+-- name: CreateFileFaq :one
+INSERT INTO file_faqs (
+  file_id, question, answer
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: UpdateFileFaq :execrows
+UPDATE file_faqs
+SET question = $1,
+    answer = $2
+WHERE id = $3 AND file_id = $4;
+
+-- name: DeleteFileFaq :execrows
+DELETE FROM file_faqs
+WHERE id = $1 AND file_id = $2;
+
+-- name: DeleteFileFaqs :exec
+DELETE FROM file_faqs
+WHERE file_id = $1;
+
+-- name: GetNoteEdits :many
+SELECT * FROM file_note_edits
+WHERE file_id = $1
+ORDER BY id DESC
+LIMIT 20;
+
+-- name: GetNoteEdit :one
+SELECT * FROM file_note_edits
+WHERE id = $1 AND file_id = $2
+LIMIT 1;
+
+-- name: CreateNoteEdit :exec
+INSERT INTO file_note_edits (
+  file_id, description, snapshot
+) VALUES (
+  $1, $2, $3
+);
+
+-- name: GetDeletedFiles :many
+SELECT * FROM files
+WHERE username = $1 AND deleted_at IS NOT NULL AND superseded_at IS NULL
+ORDER BY deleted_at DESC;
+
+-- name: SoftDeleteFile :execrows
+WITH note AS (
+  SELECT COALESCE(version_group_id, id) AS group_id FROM files
+  WHERE files.id = $1 AND files.username = $2
+)
+UPDATE files
+SET deleted_at = CURRENT_TIMESTAMP
+FROM note
+WHERE files.username = $2 AND files.deleted_at IS NULL
+  AND (files.id = note.group_id OR files.version_group_id = note.group_id);
 
--- name: DeleteFile :exec
+-- name: RestoreFile :execrows
+WITH note AS (
+  SELECT COALESCE(version_group_id, id) AS group_id FROM files
+  WHERE files.id = $1 AND files.username = $2
+)
+UPDATE files
+SET deleted_at = NULL
+FROM note
+WHERE files.username = $2 AND files.deleted_at IS NOT NULL
+  AND (files.id = note.group_id OR files.version_group_id = note.group_id);
+
+-- name: DeleteFile :many
+WITH note AS (
+  SELECT COALESCE(version_group_id, id) AS group_id FROM files
+  WHERE files.id = $1 AND files.username = $2
+)
+DELETE FROM files
+USING note
+WHERE files.username = $2 AND files.deleted_at IS NOT NULL
+  AND (files.id = note.group_id OR files.version_group_id = note.group_id)
+RETURNING files.*;
+
+-- name: PurgeDeletedFiles :many
 DELETE FROM files
-WHERE id = $1;
\ No newline at end of file
+WHERE (deleted_at IS NOT NULL AND deleted_at < $1)
+  OR (superseded_at IS NOT NULL AND NOT EXISTS (
+    SELECT 1 FROM files AS latest
+    WHERE latest.superseded_at IS NULL
+      AND latest.version_group_id = COALESCE(files.version_group_id, files.id)
+  ))
+RETURNING *;
+
+-- name: CountFilesByName :one
+SELECT COUNT(*) FROM files
+WHERE username = $1 AND file_name = $2 AND superseded_at IS NULL;
+
+-- name: CreatePendingCleanup :exec
+INSERT INTO pending_cleanups (
+  username, file_name, llama_cloud_file_id, last_error
+) VALUES (
+  $1, $2, $3, $4
+);
+
+-- name: GetPendingCleanups :many
+SELECT * FROM pending_cleanups
+WHERE attempts < $1
+ORDER BY created_at ASC;
+
+-- name: UpdatePendingCleanup :exec
+UPDATE pending_cleanups
+SET attempts = attempts + 1, last_error = $2
+WHERE id = $1;
+
+-- name: DeletePendingCleanup :exec
+DELETE FROM pending_cleanups
+WHERE id = $1;
+
+-- name: SetFileContent :exec
+UPDATE files
+SET content = $2
+WHERE id = $1;
+
+-- name: GetNoteDrafts :many
+SELECT * FROM note_drafts
+WHERE username = $1
+ORDER BY updated_at DESC;
+
+-- name: GetNoteDraft :one
+SELECT * FROM note_drafts
+WHERE id = $1 AND username = $2;
+
+-- name: CreateNoteDraft :one
+INSERT INTO note_drafts (
+  username, title, content
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: UpdateNoteDraft :one
+UPDATE note_drafts
+SET title = $3, content = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND username = $2
+RETURNING *;
+
+-- name: DeleteNoteDraft :exec
+DELETE FROM note_drafts
+WHERE id = $1 AND username = $2;
+
+-- name: GetFileVersions :many
+SELECT * FROM files
+WHERE username = $1 AND deleted_at IS NULL AND (id = $2 OR version_group_id = $2)
+ORDER BY version DESC;
+
+-- name: SetFileVersion :exec
+UPDATE files
+SET version_group_id = $2, version = $3
+WHERE id = $1;
+
+-- name: SupersedeFile :exec
+UPDATE files
+SET superseded_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: GetTags :many
+SELECT name FROM tags
+WHERE username = $1
+ORDER BY name;
+
+-- name: GetFileTags :many
+SELECT tags.name FROM tags
+JOIN file_tags ON file_tags.tag_id = tags.id
+WHERE file_tags.file_id = $1
+ORDER BY tags.name;
+
+-- name: GetUserFileTags :many
+SELECT file_tags.file_id, tags.name FROM tags
+JOIN file_tags ON file_tags.tag_id = tags.id
+WHERE tags.username = $1
+ORDER BY tags.name;
+
+-- name: GetTaggedFileNames :many
+SELECT DISTINCT files.file_name FROM files
+JOIN file_tags ON file_tags.file_id = files.id
+JOIN tags ON tags.id = file_tags.tag_id
+WHERE files.username = sqlc.arg(username)
+  AND files.deleted_at IS NULL
+  AND files.superseded_at IS NULL
+  AND tags.name = ANY(sqlc.arg(tags)::text[]);
+
+-- name: UpsertTag :one
+INSERT INTO tags (
+  username, name
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (username, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: AddFileTag :exec
+INSERT INTO file_tags (
+  file_id, tag_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFileTags :exec
+DELETE FROM file_tags
+WHERE file_id = $1;
+
+-- name: DeleteUnusedTags :exec
+DELETE FROM tags
+WHERE username = $1 AND NOT EXISTS (
+  SELECT 1 FROM file_tags WHERE file_tags.tag_id = tags.id
+);
+
+
+-- name: SetCategoryCourse :exec
+UPDATE files
+SET course_id = sqlc.narg(course_id)
+WHERE username = sqlc.arg(username) AND file_category = sqlc.arg(file_category)::text;
+
+-- name: SetFileCourse :exec
+UPDATE files
+SET course_id = $2
+WHERE id = $1;
+
+-- name: ClearFilesCourse :exec
+UPDATE files
+SET course_id = NULL
+WHERE username = sqlc.arg(username) AND course_id = sqlc.arg(course_id)::int;
+
+-- name: GetCourseFileNames :many
+SELECT DISTINCT file_name FROM files
+WHERE username = sqlc.arg(username)
+  AND deleted_at IS NULL
+  AND superseded_at IS NULL
+  AND course_id = ANY(sqlc.arg(course_ids)::int[]);
+
+-- name: RenameFileCategory :many
+UPDATE files
+SET file_category = sqlc.arg(new_category)::text
+WHERE username = sqlc.arg(username) AND file_category = sqlc.arg(old_category)::text
+RETURNING *;
+-- name: GetCategoryNoteStats :many
+SELECT file_category, COUNT(*) AS note_count, MAX(uploaded_at)::timestamp AS last_upload FROM files
+WHERE username = $1 AND file_category IS NOT NULL AND deleted_at IS NULL AND superseded_at IS NULL
+GROUP BY file_category;
+
+-- name: GetCategoryFaqCounts :many
+SELECT files.file_category, COUNT(file_faqs.id) AS faq_count FROM file_faqs
+JOIN files ON files.id = file_faqs.file_id
+WHERE files.username = $1 AND files.file_category IS NOT NULL AND files.deleted_at IS NULL AND files.superseded_at IS NULL
+GROUP BY files.file_category;
+
+-- name: GetWeeklyUploads :many
+SELECT date_trunc('week', uploaded_at)::timestamp AS week, COUNT(*) AS note_count FROM files
+WHERE username = sqlc.arg(username) AND deleted_at IS NULL AND superseded_at IS NULL AND uploaded_at >= sqlc.arg(since)::timestamp
+GROUP BY week
+ORDER BY week;
+
+-- name: CreateSearchHit :exec
+INSERT INTO search_hits (username, category, hits, best_similarity)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetCategorySearchStats :many
+SELECT category, COUNT(*) AS search_count, SUM(hits)::bigint AS hit_count, AVG(best_similarity)::float AS avg_similarity FROM search_hits
+WHERE username = sqlc.arg(username) AND searched_at >= sqlc.arg(since)::timestamp
+GROUP BY category;
+
+-- name: RenameSearchHitsCategory :exec
+UPDATE search_hits
+SET category = sqlc.arg(new_category)::text
+WHERE username = sqlc.arg(username) AND category = sqlc.arg(old_category)::text;
+
+-- name: CreateSearchHistoryEntry :exec
+INSERT INTO search_history (username, search_type, search_input, file_name, category, course_id, tags, result_count, file_names, categories, excluded_file_names, excluded_categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+
+-- name: TrimSearchHistory :exec
+DELETE FROM search_history
+WHERE username = sqlc.arg(username) AND id NOT IN (
+  SELECT id FROM search_history
+  WHERE username = sqlc.arg(username)
+  ORDER BY searched_at DESC, id DESC
+  LIMIT sqlc.arg(keep)::int
+);
+
+-- name: GetSearchHistory :many
+SELECT * FROM search_history
+WHERE username = $1
+ORDER BY searched_at DESC, id DESC
+LIMIT $2;
+
+-- name: GetSearchHistoryEntry :one
+SELECT * FROM search_history
+WHERE id = $1 AND username = $2;
+
+-- name: TouchSearchHistoryEntry :exec
+UPDATE search_history
+SET searched_at = CURRENT_TIMESTAMP, result_count = $3
+WHERE id = $1 AND username = $2;
+
+-- name: DeleteSearchHistoryEntry :execrows
+DELETE FROM search_history
+WHERE id = $1 AND username = $2;
+
+-- name: ClearSearchHistory :exec
+DELETE FROM search_history
+WHERE username = $1;
+
+-- name: GetSearchHistoryEnabled :one
+SELECT history_enabled FROM search_settings
+WHERE username = $1;
+
+-- name: SetSearchHistoryEnabled :exec
+INSERT INTO search_settings (username, history_enabled)
+VALUES ($1, $2)
+ON CONFLICT (username) DO UPDATE SET history_enabled = EXCLUDED.history_enabled;
+
+-- name: CreateSavedSearch :exec
+INSERT INTO saved_searches (username, search_type, search_input, file_name, category, course_id, tags, file_names, categories, excluded_file_names, excluded_categories)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+
+-- name: CountSavedSearches :one
+SELECT COUNT(*) FROM saved_searches
+WHERE username = $1;
+
+-- name: GetSavedSearches :many
+SELECT * FROM saved_searches
+WHERE username = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: GetSavedSearch :one
+SELECT * FROM saved_searches
+WHERE id = $1 AND username = $2;
+
+-- name: DeleteSavedSearch :execrows
+DELETE FROM saved_searches
+WHERE id = $1 AND username = $2;
+
+-- name: UpsertSearchAlert :exec
+INSERT INTO search_alerts (username, saved_search_id, file_id, hits, best_similarity, excerpt)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (saved_search_id, file_id) DO UPDATE
+SET hits = EXCLUDED.hits, best_similarity = EXCLUDED.best_similarity, excerpt = EXCLUDED.excerpt, created_at = CURRENT_TIMESTAMP, read_at = NULL;
+
+-- name: GetSearchAlerts :many
+SELECT search_alerts.id, search_alerts.saved_search_id, search_alerts.file_id, search_alerts.hits, search_alerts.best_similarity, search_alerts.excerpt, search_alerts.created_at, search_alerts.read_at, saved_searches.search_input, files.file_name FROM search_alerts
+JOIN saved_searches ON saved_searches.id = search_alerts.saved_search_id
+JOIN files ON files.id = search_alerts.file_id
+WHERE search_alerts.username = $1 AND files.deleted_at IS NULL
+ORDER BY search_alerts.created_at DESC, search_alerts.id DESC
+LIMIT $2;
+
+-- name: CountUnreadSearchAlerts :one
+SELECT COUNT(*) FROM search_alerts
+JOIN files ON files.id = search_alerts.file_id
+WHERE search_alerts.username = $1 AND search_alerts.read_at IS NULL AND files.deleted_at IS NULL;
+
+-- name: MarkSearchAlertsRead :exec
+UPDATE search_alerts
+SET read_at = CURRENT_TIMESTAMP
+WHERE username = $1 AND read_at IS NULL;
+
+-- name: DeleteSearchAlert :execrows
+DELETE FROM search_alerts
+WHERE id = $1 AND username = $2;
+
+-- name: ClearSearchAlerts :exec
+DELETE FROM search_alerts
+WHERE username = $1;
+
+-- name: KeywordSearchNotes :many
+SELECT files.file_name, files.file_category, matches.result_type, matches.text, matches.rank FROM (
+  SELECT file_id, 'summary'::text AS result_type, summary AS text,
+    ts_rank(to_tsvector('english', summary), websearch_to_tsquery('english', sqlc.arg(query)::text), 32)::float AS rank
+  FROM file_summaries
+  WHERE sqlc.arg(summaries)::bool AND to_tsvector('english', summary) @@ websearch_to_tsquery('english', sqlc.arg(query)::text)
+  UNION ALL
+  SELECT file_id, 'answer'::text, answer,
+    ts_rank(to_tsvector('english', question || ' ' || answer), websearch_to_tsquery('english', sqlc.arg(query)::text), 32)::float
+  FROM file_faqs
+  WHERE sqlc.arg(faqs)::bool AND to_tsvector('english', question || ' ' || answer) @@ websearch_to_tsquery('english', sqlc.arg(query)::text)
+) AS matches
+JOIN files ON files.id = matches.file_id
+WHERE files.username = sqlc.arg(username) AND files.deleted_at IS NULL AND files.superseded_at IS NULL
+  AND (cardinality(sqlc.arg(file_names)::text[]) = 0 OR files.file_name = ANY(sqlc.arg(file_names)::text[]))
+  AND (cardinality(sqlc.arg(categories)::text[]) = 0 OR files.file_category = ANY(sqlc.arg(categories)::text[]))
+  AND NOT files.file_name = ANY(sqlc.arg(excluded_file_names)::text[])
+  AND (files.file_category IS NULL OR NOT files.file_category = ANY(sqlc.arg(excluded_categories)::text[]))
+  AND (sqlc.narg(course_id)::int IS NULL OR files.course_id = sqlc.narg(course_id)::int)
+  AND (cardinality(sqlc.arg(tags)::text[]) = 0 OR EXISTS (
+    SELECT 1 FROM file_tags
+    JOIN tags ON tags.id = file_tags.tag_id
+    WHERE file_tags.file_id = files.id AND tags.name = ANY(sqlc.arg(tags)::text[])
+  ))
+ORDER BY matches.rank DESC, files.id DESC
+LIMIT sqlc.arg(max_results)::int OFFSET sqlc.arg(skip)::int;
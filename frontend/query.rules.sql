@@ -1,21 +1,155 @@
 -- name: GetRules :many
 SELECT * FROM rules
-WHERE username = $1;
+WHERE username = $1 AND deleted_at IS NULL;
+
+-- name: GetClassificationRules :many
+SELECT * FROM rules
+WHERE username = $1 AND deleted_at IS NULL
+  AND (course_id IS NULL OR course_id NOT IN (
+    SELECT id FROM courses WHERE archived_at IS NOT NULL
+  ));
+
+-- name: GetAllRules :many
+SELECT * FROM rules
+WHERE username = $1 AND deleted_at IS NULL
+ORDER BY rule_name;
 
 -- name: CreateRule :one
 INSERT INTO rules (
-  username, rule_name, rule_type, rule_description
+  username, rule_name, rule_type, display_label, rule_description, parent_id
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6
 )
 RETURNING *;
 
--- name: UpdateRule :exec
+-- name: GetRule :one
+SELECT * FROM rules
+WHERE id = $1 AND username = $2 AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: UpdateRule :one
+UPDATE rules
+SET rule_name = $1,
+    rule_type = $2,
+    display_label = $3,
+    rule_description = $4,
+    parent_id = $5
+WHERE id = $6 AND username = $7 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SetRuleParent :execrows
+UPDATE rules
+SET parent_id = $1
+WHERE id = $2 AND username = $3 AND deleted_at IS NULL;
+
+-- name: GetDeletedRules :many
+SELECT * FROM rules
+WHERE username = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: SoftDeleteRule :execrows
+UPDATE rules
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND username = $2 AND deleted_at IS NULL;
+
+-- name: RestoreRule :execrows
 UPDATE rules
-SET rule_type = $1,
-    rule_description = $2
-WHERE username = $3 AND rule_name = $4;
+SET deleted_at = NULL
+WHERE id = $1 AND username = $2 AND deleted_at IS NOT NULL;
 
--- name: DeleteRule :exec
+-- name: DeleteRule :execrows
 DELETE FROM rules
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1 AND username = $2 AND deleted_at IS NOT NULL;
+
+-- name: PurgeDeletedRules :execrows
+DELETE FROM rules
+WHERE deleted_at IS NOT NULL AND deleted_at < $1;
+
+-- name: GetCourses :many
+SELECT * FROM courses
+WHERE username = $1
+ORDER BY archived_at IS NOT NULL, semester DESC, name;
+
+-- name: GetCourse :one
+SELECT * FROM courses
+WHERE id = $1 AND username = $2
+LIMIT 1;
+
+-- name: CreateCourse :one
+INSERT INTO courses (
+  username, name, semester
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: ArchiveCourse :execrows
+UPDATE courses
+SET archived_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND username = $2 AND archived_at IS NULL;
+
+-- name: UnarchiveCourse :execrows
+UPDATE courses
+SET archived_at = NULL
+WHERE id = $1 AND username = $2 AND archived_at IS NOT NULL;
+
+-- name: DeleteCourse :execrows
+DELETE FROM courses
+WHERE id = $1 AND username = $2;
+
+-- name: GetArchivedCourseIds :many
+SELECT id FROM courses
+WHERE username = $1 AND archived_at IS NOT NULL;
+
+-- name: GetCourseRules :many
+SELECT * FROM rules
+WHERE username = sqlc.arg(username) AND course_id = sqlc.arg(course_id)::int AND deleted_at IS NULL
+ORDER BY rule_name;
+
+-- name: SetRuleCourse :one
+UPDATE rules
+SET course_id = sqlc.narg(course_id)
+WHERE id = sqlc.arg(id) AND username = sqlc.arg(username) AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetPublishedPresets :many
+SELECT * FROM category_presets
+ORDER BY preset_key;
+
+-- name: PublishPreset :one
+INSERT INTO category_presets (
+  preset_key, name, label, group_name, description, version, published_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (preset_key) DO UPDATE
+SET name = EXCLUDED.name,
+    label = EXCLUDED.label,
+    group_name = EXCLUDED.group_name,
+    description = EXCLUDED.description,
+    version = EXCLUDED.version,
+    published_by = EXCLUDED.published_by,
+    published_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeletePublishedPreset :execrows
+DELETE FROM category_presets
+WHERE preset_key = $1;
+
+-- name: GetPresetRules :many
+SELECT * FROM rules
+WHERE username = $1 AND preset_key IS NOT NULL AND deleted_at IS NULL;
+
+-- name: CreatePresetRule :one
+INSERT INTO rules (
+  username, rule_name, rule_type, rule_description, preset_key, preset_version
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: UpdatePresetRule :execrows
+UPDATE rules
+SET rule_description = $1,
+    preset_version = $2
+WHERE id = $3 AND username = $4 AND deleted_at IS NULL;
\ No newline at end of file
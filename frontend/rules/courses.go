@@ -0,0 +1,58 @@
+package rules
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+const maxCourseNameLength = 100
+
+// Semester groups the courses taken in the same semester
+type Semester struct {
+	Name    string
+	Courses []rulesdb.Course
+}
+
+// ParseCourse trims and validates the name and the semester of a course,
+// the latter being optional
+func ParseCourse(name, semester string) (string, string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	semester = strings.Join(strings.Fields(semester), " ")
+	if name == "" {
+		return "", "", errors.New("the course needs a name")
+	}
+	if len([]rune(name)) > maxCourseNameLength || len([]rune(semester)) > maxCourseNameLength {
+		return "", "", errors.New("course names and semesters are limited to 100 characters")
+	}
+	return name, semester, nil
+}
+
+// GroupBySemester splits courses by semester, keeping the order in which
+// they are given
+func GroupBySemester(courses []rulesdb.Course) []Semester {
+	semesters := []Semester{}
+	for _, course := range courses {
+		idx := slices.IndexFunc(semesters, func(semester Semester) bool { return semester.Name == course.Semester })
+		if idx < 0 {
+			semesters = append(semesters, Semester{Name: course.Semester})
+			idx = len(semesters) - 1
+		}
+		semesters[idx].Courses = append(semesters[idx].Courses, course)
+	}
+	return semesters
+}
+
+// CourseForCategory returns the course of the category whose label matches a
+// file category, so that notes are filed under the course of their category
+func CourseForCategory(rules []rulesdb.Rule, category string) pgtype.Int4 {
+	for _, rule := range rules {
+		if NormalizeLabel(rule.RuleType) == category {
+			return rule.CourseID
+		}
+	}
+	return pgtype.Int4{}
+}
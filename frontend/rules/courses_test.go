@@ -0,0 +1,56 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+func TestParseCourse(t *testing.T) {
+	name, semester, err := ParseCourse("  Cell   Biology ", " Fall 2026 ")
+	if err != nil {
+		t.Fatalf("Not expecting an error, got %s", err.Error())
+	}
+	if name != "Cell Biology" || semester != "Fall 2026" {
+		t.Errorf("Expecting the whitespace to be collapsed, got %q and %q", name, semester)
+	}
+	if _, _, err := ParseCourse("   ", "Fall 2026"); err == nil {
+		t.Error("Expecting an error for a course without a name")
+	}
+}
+
+func TestGroupBySemester(t *testing.T) {
+	courses := []rulesdb.Course{
+		{ID: 1, Name: "Calculus", Semester: "Spring 2026"},
+		{ID: 2, Name: "Biology", Semester: "Fall 2025"},
+		{ID: 3, Name: "Physics", Semester: "Spring 2026"},
+		{ID: 4, Name: "Reading group", Semester: ""},
+	}
+	semesters := GroupBySemester(courses)
+	if len(semesters) != 3 {
+		t.Fatalf("Expecting 3 semesters, got %d", len(semesters))
+	}
+	if semesters[0].Name != "Spring 2026" || len(semesters[0].Courses) != 2 || semesters[0].Courses[1].ID != 3 {
+		t.Errorf("Expecting the Spring 2026 courses to be grouped together, got %v", semesters[0])
+	}
+	if semesters[2].Name != "" || semesters[2].Courses[0].ID != 4 {
+		t.Errorf("Expecting courses without a semester to be grouped last, got %v", semesters[2])
+	}
+}
+
+func TestCourseForCategory(t *testing.T) {
+	rules := []rulesdb.Rule{
+		{RuleType: "Molecular Biology", CourseID: pgtype.Int4{Int32: 7, Valid: true}},
+		{RuleType: "history"},
+	}
+	if course := CourseForCategory(rules, "molecular_biology"); !course.Valid || course.Int32 != 7 {
+		t.Errorf("Expecting the note to be filed under course 7, got %v", course)
+	}
+	if course := CourseForCategory(rules, "history"); course.Valid {
+		t.Errorf("Expecting no course for a category outside of any course, got %v", course)
+	}
+	if course := CourseForCategory(rules, "unknown"); course.Valid {
+		t.Errorf("Expecting no course for an unknown category, got %v", course)
+	}
+}
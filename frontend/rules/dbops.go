@@ -2,16 +2,40 @@ package rules
 
 import (
 	"context"
+	"embed"
+	"errors"
 	"os"
-
-	_ "embed"
+	"path"
+	"sync/atomic"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	uniqueViolationCode = "23505"
+	ruleLabelIndex      = "rules_username_label_idx"
+	// migrationsLock is the advisory lock held while applying the migrations
+	migrationsLock = 7_310_036
+)
+
+var (
+	ErrRuleNameTaken  = errors.New("a category with this name already exists")
+	ErrRuleLabelTaken = errors.New("another category already uses this label")
 )
 
 //go:embed schema.sql
 var ddl string
 
+// migrations are the one-time data migrations of the categories, applied in
+// the order of their names once the schema is in place
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrated tells whether this process already applied the migrations
+var migrated atomic.Bool
+
 func CreateNewDb() (*pgx.Conn, error) {
 	ctx := context.Background()
 	connString := os.Getenv("POSTGRES_CONNECTION_STRING")
@@ -23,5 +47,69 @@ func CreateNewDb() (*pgx.Conn, error) {
 	if err != nil {
 		return nil, err
 	}
+	if !migrated.Load() {
+		if err := migrate(ctx, db); err != nil {
+			return nil, err
+		}
+		migrated.Store(true)
+	}
 	return db, nil
 }
+
+// migrate applies the migrations that were not applied yet, recording each of
+// them in the same transaction. Connections migrating at the same time wait for
+// each other on an advisory lock.
+func migrate(ctx context.Context, db *pgx.Conn) error {
+	entries, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationsLock); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		var applied bool
+		err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM rules_migrations WHERE name = $1)", entry.Name()).Scan(&applied)
+		if err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+		migration, err := migrations.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, string(migration)); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, "INSERT INTO rules_migrations (name) VALUES ($1)", entry.Name()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// IsUniqueViolation reports whether a query failed because of a unique constraint
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+// RuleConflict translates a unique violation on the rules table into an error
+// that can be shown to the user, and returns nil for any other error
+func RuleConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolationCode {
+		return nil
+	}
+	if pgErr.ConstraintName == ruleLabelIndex {
+		return ErrRuleLabelTaken
+	}
+	return ErrRuleNameTaken
+}
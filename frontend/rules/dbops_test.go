@@ -1,8 +1,13 @@
 package rules
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 func TestCreateDb(t *testing.T) {
@@ -15,3 +20,39 @@ func TestCreateDb(t *testing.T) {
 		}
 	}
 }
+
+func TestIsUniqueViolation(t *testing.T) {
+	if !IsUniqueViolation(fmt.Errorf("creating the course: %w", &pgconn.PgError{Code: "23505"})) {
+		t.Error("Expecting a wrapped unique violation to be detected")
+	}
+	if IsUniqueViolation(&pgconn.PgError{Code: "23503"}) || IsUniqueViolation(errors.New("23505")) {
+		t.Error("Not expecting other errors to be reported as unique violations")
+	}
+}
+
+func TestRuleConflict(t *testing.T) {
+	if err := RuleConflict(&pgconn.PgError{Code: "23505", ConstraintName: "rules_username_label_idx"}); err != ErrRuleLabelTaken {
+		t.Errorf("Expecting a label conflict, got %v", err)
+	}
+	if err := RuleConflict(&pgconn.PgError{Code: "23505", ConstraintName: "rules_username_rule_name_idx"}); err != ErrRuleNameTaken {
+		t.Errorf("Expecting a name conflict, got %v", err)
+	}
+	if err := RuleConflict(errors.New("connection refused")); err != nil {
+		t.Errorf("Not expecting a conflict for other errors, got %v", err)
+	}
+}
+
+func TestSchemaLeavesDataToMigrations(t *testing.T) {
+	if strings.Contains(ddl, "UPDATE rules") {
+		t.Error("Expecting the data migrations to run once from the migrations, not from the schema run on every connection")
+	}
+	entries, err := migrations.ReadDir("migrations")
+	if err != nil || len(entries) == 0 {
+		t.Fatalf("Expecting embedded migrations, got %v and %v", entries, err)
+	}
+	for idx := 1; idx < len(entries); idx++ {
+		if entries[idx-1].Name() >= entries[idx].Name() {
+			t.Errorf("Expecting the migrations in the order of their names, got %s before %s", entries[idx-1].Name(), entries[idx].Name())
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package rules
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+// DraftRule is a category being written or edited, tested against a sample
+// note before it is saved. ReplacedID is the id of the category it edits, or 0
+// for a new category.
+type DraftRule struct {
+	ReplacedID  int32
+	ParentID    int32
+	Name        string
+	Label       string
+	Description string
+}
+
+// IsEmpty reports whether nothing was written in the draft
+func (d DraftRule) IsEmpty() bool {
+	return strings.TrimSpace(d.Label) == "" && strings.TrimSpace(d.Description) == ""
+}
+
+// DraftRuleSet returns the categories a sample note is classified against:
+// the saved categories, where the draft replaces the category it edits or is
+// added as a new one
+func DraftRuleSet(userRules []rulesdb.Rule, draft DraftRule) ([]rulesdb.Rule, error) {
+	ruleSet := make([]rulesdb.Rule, 0, len(userRules)+1)
+	for _, rule := range userRules {
+		if draft.ReplacedID == 0 || rule.ID != draft.ReplacedID {
+			ruleSet = append(ruleSet, rule)
+		}
+	}
+	if !draft.IsEmpty() {
+		label, err := CanonicalLabel(draft.Label)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(draft.Description) == "" {
+			return nil, errors.New("the draft category needs a description")
+		}
+		for _, rule := range ruleSet {
+			if NormalizeLabel(rule.RuleType) == label {
+				return nil, errors.New("the draft category uses the same label as " + rule.RuleName)
+			}
+		}
+		if err := ValidateParent(userRules, draft.ReplacedID, draft.ParentID); err != nil {
+			return nil, err
+		}
+		name := strings.TrimSpace(draft.Name)
+		if name == "" {
+			name = "Draft category"
+		}
+		parent := pgtype.Int4{Int32: draft.ParentID, Valid: draft.ParentID != 0}
+		ruleSet = append(ruleSet, rulesdb.Rule{ID: draft.ReplacedID, RuleName: name, RuleType: label, DisplayLabel: strings.TrimSpace(draft.Label), RuleDescription: draft.Description, ParentID: parent})
+	}
+	if len(ruleSet) == 0 {
+		return nil, errors.New("create a category or write a draft to run a test")
+	}
+	return ruleSet, nil
+}
+
+// FindRuleByLabel returns the category classifying notes under a label
+func FindRuleByLabel(ruleSet []rulesdb.Rule, label string) (rulesdb.Rule, bool) {
+	for _, rule := range ruleSet {
+		if NormalizeLabel(rule.RuleType) == NormalizeLabel(label) {
+			return rule, true
+		}
+	}
+	return rulesdb.Rule{}, false
+}
@@ -0,0 +1,49 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+func TestDraftRuleSet(t *testing.T) {
+	saved := []rulesdb.Rule{
+		{ID: 1, RuleName: "Biology", RuleType: "biology", RuleDescription: "Cells"},
+		{ID: 2, RuleName: "History", RuleType: "history", RuleDescription: "Wars"},
+	}
+	ruleSet, err := DraftRuleSet(saved, DraftRule{})
+	if err != nil || len(ruleSet) != 2 {
+		t.Errorf("Expected the saved categories without a draft, got %v (%v)", ruleSet, err)
+	}
+
+	ruleSet, err = DraftRuleSet(saved, DraftRule{Name: "Physics", Label: "Physics ", Description: "Forces"})
+	if err != nil || len(ruleSet) != 3 || ruleSet[2].RuleType != "physics" {
+		t.Errorf("Expected the draft to be added, got %v (%v)", ruleSet, err)
+	}
+
+	ruleSet, err = DraftRuleSet(saved, DraftRule{ReplacedID: 2, Name: "History", Label: "history", Description: "Wars and revolutions"})
+	if err != nil || len(ruleSet) != 2 || ruleSet[1].RuleDescription != "Wars and revolutions" {
+		t.Errorf("Expected the draft to replace the edited category, got %v (%v)", ruleSet, err)
+	}
+	rule, ok := FindRuleByLabel(ruleSet, "History")
+	if !ok || rule.ID != 2 {
+		t.Errorf("Expected to find the draft by label, got %v", rule)
+	}
+
+	if _, err := DraftRuleSet(saved, DraftRule{Label: "biology", Description: "Plants"}); err == nil {
+		t.Error("Expected an error for a draft reusing a label")
+	}
+	if _, err := DraftRuleSet(saved, DraftRule{Label: "physics"}); err == nil {
+		t.Error("Expected an error for a draft without a description")
+	}
+	ruleSet, err = DraftRuleSet(saved, DraftRule{ParentID: 1, Name: "Genetics", Label: "genetics", Description: "Genes"})
+	if err != nil || ParentOf(ruleSet, ruleSet[2]) != 1 {
+		t.Errorf("Expected the draft to be placed under Biology, got %v (%v)", ruleSet, err)
+	}
+	if _, err := DraftRuleSet(saved, DraftRule{ReplacedID: 1, ParentID: 1, Label: "biology", Description: "Cells"}); err == nil {
+		t.Error("Expected an error for a draft placed under itself")
+	}
+	if _, err := DraftRuleSet(nil, DraftRule{}); err == nil {
+		t.Error("Expected an error without any category")
+	}
+}
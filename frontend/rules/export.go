@@ -0,0 +1,257 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	exportVersion         = 1
+	maxImportedCategories = 500
+)
+
+// ExportedRule is a category as it appears in an export document
+type ExportedRule struct {
+	Name        string `json:"name" yaml:"name"`
+	Label       string `json:"label" yaml:"label"`
+	Description string `json:"description" yaml:"description"`
+	Parent      string `json:"parent,omitempty" yaml:"parent,omitempty"`
+}
+
+// Export is a document holding the categories of a user, used to back them up
+// and to share them across accounts
+type Export struct {
+	Version    int            `json:"version" yaml:"version"`
+	Categories []ExportedRule `json:"categories" yaml:"categories"`
+}
+
+// NewExport builds the export document of a user's categories
+func NewExport(userRules []rulesdb.Rule) Export {
+	export := Export{Version: exportVersion, Categories: make([]ExportedRule, 0, len(userRules))}
+	for _, entry := range FlattenTree(userRules) {
+		category := ExportedRule{Name: entry.Rule.RuleName, Label: DisplayLabel(entry.Rule), Description: entry.Rule.RuleDescription}
+		if len(entry.Path) > 0 {
+			category.Parent = entry.Path[len(entry.Path)-1]
+		}
+		export.Categories = append(export.Categories, category)
+	}
+	return export
+}
+
+// MarshalExport encodes an export document as JSON or YAML
+func MarshalExport(export Export, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(export, "", "  ")
+	case "yaml":
+		return yaml.Marshal(export)
+	default:
+		return nil, errors.New("unsupported export format: " + format)
+	}
+}
+
+// ParseExport decodes an export document. JSON documents start with a brace,
+// anything else is read as YAML. Unknown fields are rejected so that typos do
+// not silently drop data.
+func ParseExport(data []byte) (Export, error) {
+	var export Export
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return export, errors.New("the document is empty")
+	}
+	if trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&export); err != nil {
+			return export, errors.New("invalid JSON document: " + err.Error())
+		}
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(trimmed))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&export); err != nil {
+			return export, errors.New("invalid YAML document: " + err.Error())
+		}
+	}
+	return export, ValidateExport(export)
+}
+
+// ValidateExport checks that every category of the document can be created,
+// and that the document does not contain the same name or label twice
+func ValidateExport(export Export) error {
+	if export.Version != exportVersion {
+		return fmt.Errorf("unsupported document version %d", export.Version)
+	}
+	if len(export.Categories) == 0 {
+		return errors.New("the document has no categories")
+	}
+	if len(export.Categories) > maxImportedCategories {
+		return fmt.Errorf("a document may contain at most %d categories", maxImportedCategories)
+	}
+	names := map[string]bool{}
+	labels := map[string]bool{}
+	for idx, category := range export.Categories {
+		name := strings.TrimSpace(category.Name)
+		label := NormalizeLabel(category.Label)
+		if name == "" || strings.TrimSpace(category.Description) == "" {
+			return fmt.Errorf("category %d: the name and the description are required", idx+1)
+		}
+		if err := ValidateLabelKey(label); err != nil {
+			return fmt.Errorf("category %q: %w", name, err)
+		}
+		if names[name] {
+			return fmt.Errorf("category %q appears more than once", name)
+		}
+		if labels[label] {
+			return fmt.Errorf("label %q appears more than once", label)
+		}
+		if strings.TrimSpace(category.Parent) == name {
+			return fmt.Errorf("category %q cannot be its own parent", name)
+		}
+		names[name] = true
+		labels[label] = true
+	}
+	return nil
+}
+
+// ImportAction is what importing a category does to the user's categories
+type ImportAction string
+
+const (
+	ImportAdd       ImportAction = "add"
+	ImportUpdate    ImportAction = "update"
+	ImportUnchanged ImportAction = "unchanged"
+	ImportConflict  ImportAction = "conflict"
+)
+
+// ImportItem is the planned outcome of importing one category. Existing is the
+// category with the same name, for updates and unchanged categories.
+type ImportItem struct {
+	Category ExportedRule
+	Action   ImportAction
+	Existing *rulesdb.Rule
+	Reason   string
+}
+
+// PlanImport matches the categories of a document against the existing ones
+// by name. Categories with a new name are added and the ones with a known name
+// are updated, unless their label is already used by another category.
+func PlanImport(export Export, existing []rulesdb.Rule) []ImportItem {
+	plan := make([]ImportItem, 0, len(export.Categories))
+	for _, category := range export.Categories {
+		category.Name = strings.TrimSpace(category.Name)
+		category.Label = strings.TrimSpace(category.Label)
+		category.Parent = strings.TrimSpace(category.Parent)
+		item := ImportItem{Category: category, Action: ImportAdd}
+		label := NormalizeLabel(category.Label)
+		for idx := range existing {
+			rule := &existing[idx]
+			if rule.RuleName == category.Name {
+				item.Existing = rule
+			} else if NormalizeLabel(rule.RuleType) == label {
+				item.Action = ImportConflict
+				item.Reason = fmt.Sprintf("the label is already used by %q", rule.RuleName)
+			}
+		}
+		if item.Action != ImportConflict && item.Existing != nil {
+			if NormalizeLabel(item.Existing.RuleType) == label && item.Existing.RuleDescription == category.Description && parentName(existing, *item.Existing) == category.Parent {
+				item.Action = ImportUnchanged
+			} else {
+				item.Action = ImportUpdate
+			}
+		}
+		plan = append(plan, item)
+	}
+	return plan
+}
+
+// parentName returns the name of the parent of a category, if it has one
+func parentName(userRules []rulesdb.Rule, rule rulesdb.Rule) string {
+	parentId := ParentOf(userRules, rule)
+	if parentId == 0 {
+		return ""
+	}
+	idx := slices.IndexFunc(userRules, func(r rulesdb.Rule) bool { return r.ID == parentId })
+	return userRules[idx].RuleName
+}
+
+// CountImportActions counts the planned categories by action
+func CountImportActions(plan []ImportItem) map[ImportAction]int {
+	counts := map[ImportAction]int{}
+	for _, item := range plan {
+		counts[item.Action]++
+	}
+	return counts
+}
+
+// ApplyImport adds and updates the categories of an import plan in a single
+// transaction, skipping conflicts and unchanged categories. Parents are set
+// once all the categories exist, and are looked up by name. relabel is called
+// in the transaction with the normalized labels of each updated category, to
+// move what is filed under the previous label.
+func ApplyImport(ctx context.Context, db *pgx.Conn, username string, plan []ImportItem, relabel func(tx pgx.Tx, previousLabel string, label string) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	queries := rulesdb.New(db).WithTx(tx)
+	for _, item := range plan {
+		switch item.Action {
+		case ImportAdd:
+			_, err = queries.CreateRule(ctx, rulesdb.CreateRuleParams{Username: username, RuleName: item.Category.Name, RuleType: NormalizeLabel(item.Category.Label), DisplayLabel: item.Category.Label, RuleDescription: item.Category.Description})
+		case ImportUpdate:
+			_, err = queries.UpdateRule(ctx, rulesdb.UpdateRuleParams{RuleName: item.Category.Name, RuleType: NormalizeLabel(item.Category.Label), DisplayLabel: item.Category.Label, RuleDescription: item.Category.Description, ParentID: item.Existing.ParentID, ID: item.Existing.ID, Username: username})
+			if err == nil {
+				err = relabel(tx, NormalizeLabel(item.Existing.RuleType), NormalizeLabel(item.Category.Label))
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			if conflict := RuleConflict(err); conflict != nil {
+				return fmt.Errorf("%q: %w", item.Category.Name, conflict)
+			}
+			return err
+		}
+	}
+	userRules, err := queries.GetAllRules(ctx, username)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	for _, item := range plan {
+		if item.Action != ImportAdd && item.Action != ImportUpdate {
+			continue
+		}
+		idx := slices.IndexFunc(userRules, func(r rulesdb.Rule) bool { return r.RuleName == item.Category.Name })
+		if idx < 0 {
+			continue
+		}
+		parentId := int32(0)
+		if item.Category.Parent != "" {
+			parentIdx := slices.IndexFunc(userRules, func(r rulesdb.Rule) bool { return r.RuleName == item.Category.Parent })
+			if parentIdx < 0 {
+				return fmt.Errorf("%q: the parent category %q does not exist", item.Category.Name, item.Category.Parent)
+			}
+			parentId = userRules[parentIdx].ID
+		}
+		if err := ValidateParent(userRules, userRules[idx].ID, parentId); err != nil {
+			return fmt.Errorf("%q: %w", item.Category.Name, err)
+		}
+		parent := pgtype.Int4{Int32: parentId, Valid: parentId != 0}
+		if _, err := queries.SetRuleParent(ctx, rulesdb.SetRuleParentParams{ParentID: parent, ID: userRules[idx].ID, Username: username}); err != nil {
+			return err
+		}
+		userRules[idx].ParentID = parent
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,82 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	export := NewExport([]rulesdb.Rule{
+		{ID: 1, RuleName: "Biology", RuleType: "biology", RuleDescription: "Cells and genetics"},
+		{ID: 2, RuleName: "History", RuleType: "history", RuleDescription: "Wars: causes and consequences"},
+		{ID: 3, RuleName: "Genetics", RuleType: "genetics", RuleDescription: "Genes", ParentID: pgtype.Int4{Int32: 1, Valid: true}},
+	})
+	if export.Categories[1].Name != "Genetics" || export.Categories[1].Parent != "Biology" {
+		t.Errorf("Expected subcategories to follow their parent, got %+v", export.Categories)
+	}
+	for _, format := range []string{"json", "yaml"} {
+		data, err := MarshalExport(export, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseExport(data)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(parsed.Categories) != 3 || parsed.Categories[1] != export.Categories[1] {
+			t.Errorf("%s: expected %+v, got %+v", format, export.Categories, parsed.Categories)
+		}
+	}
+	if _, err := MarshalExport(export, "xml"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
+
+func TestParseExportInvalid(t *testing.T) {
+	documents := []string{
+		"",
+		`{"version": 2, "categories": [{"name": "A", "label": "a", "description": "d"}]}`,
+		`{"version": 1, "categories": []}`,
+		`{"version": 1, "categories": [{"name": "A", "label": "a", "description": "d", "colour": "red"}]}`,
+		`{"version": 1, "categories": [{"name": "A", "label": "a", "description": ""}]}`,
+		"version: 1\ncategories:\n  - name: A\n    label: a\n    description: d\n  - name: B\n    label: ' A '\n    description: d\n",
+		"version: 1\ncategories:\n  - name: A\n    label: a\n    description: d\n  - name: A\n    label: b\n    description: d\n",
+		"not: [valid",
+		`{"version": 1, "categories": [{"name": "A", "label": "a", "description": "d", "parent": "A"}]}`,
+	}
+	for _, document := range documents {
+		if _, err := ParseExport([]byte(document)); err == nil {
+			t.Errorf("Expected an error for %q", document)
+		}
+	}
+}
+
+func TestPlanImport(t *testing.T) {
+	existing := []rulesdb.Rule{
+		{ID: 1, RuleName: "Biology", RuleType: "biology", RuleDescription: "Cells"},
+		{ID: 2, RuleName: "History", RuleType: "history", RuleDescription: "Wars"},
+		{ID: 3, RuleName: "Maths", RuleType: "maths", RuleDescription: "Numbers"},
+	}
+	export := Export{Version: 1, Categories: []ExportedRule{
+		{Name: "Biology", Label: "biology", Description: "Cells"},
+		{Name: "History", Label: "history", Description: "Wars and revolutions"},
+		{Name: "Calculus", Label: "Maths", Description: "Integrals"},
+		{Name: "Physics", Label: "physics", Description: "Forces"},
+	}}
+	plan := PlanImport(export, existing)
+	expected := []ImportAction{ImportUnchanged, ImportUpdate, ImportConflict, ImportAdd}
+	for idx, item := range plan {
+		if item.Action != expected[idx] {
+			t.Errorf("%s: expected %s, got %s", item.Category.Name, expected[idx], item.Action)
+		}
+	}
+	if plan[1].Existing == nil || plan[1].Existing.ID != 2 {
+		t.Error("Expected the update to target the existing category")
+	}
+	counts := CountImportActions(plan)
+	if counts[ImportAdd] != 1 || counts[ImportConflict] != 1 {
+		t.Errorf("Unexpected counts %v", counts)
+	}
+}
@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+const (
+	minLabelLength = 2
+	maxLabelLength = 50
+)
+
+var (
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+	labelKeyRegex   = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+)
+
+// reservedLabels cannot be used as category labels, since they read as the
+// absence of a category in filters and classification results
+var reservedLabels = []string{"all", "any", "none", "null", "other", "uncategorized", "unknown"}
+
+// NormalizeLabel mirrors the normalization applied by the classification
+// workflow, which is what ends up stored as a file category
+func NormalizeLabel(label string) string {
+	return whitespaceRegex.ReplaceAllString(strings.ToLower(strings.TrimSpace(label)), "_")
+}
+
+// CanonicalLabel turns the label typed by a user into the key the classifier
+// will produce and notes are filed under, checking that it is a valid key
+func CanonicalLabel(label string) (string, error) {
+	key := NormalizeLabel(label)
+	if err := ValidateLabelKey(key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// ValidateLabelKey checks the charset, the length and the reserved words of a
+// normalized label
+func ValidateLabelKey(key string) error {
+	if key == "" {
+		return errors.New("the label is required")
+	}
+	if len(key) < minLabelLength || len(key) > maxLabelLength {
+		return fmt.Errorf("the label must be between %d and %d characters long", minLabelLength, maxLabelLength)
+	}
+	if !labelKeyRegex.MatchString(key) {
+		return errors.New("the label may only contain letters, digits, spaces, dashes and underscores, and must start with a letter or a digit")
+	}
+	if slices.Contains(reservedLabels, key) {
+		return fmt.Errorf("%q is a reserved word and cannot be used as a label", key)
+	}
+	return nil
+}
+
+// DisplayLabel is the label of a category as the user typed it, falling back
+// to its key for categories created before labels were canonicalized
+func DisplayLabel(rule rulesdb.Rule) string {
+	if strings.TrimSpace(rule.DisplayLabel) != "" {
+		return rule.DisplayLabel
+	}
+	return rule.RuleType
+}
@@ -0,0 +1,54 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+func TestNormalizeLabel(t *testing.T) {
+	testCases := []struct {
+		label    string
+		expected string
+	}{
+		{"biology", "biology"},
+		{"  Molecular Biology ", "molecular_biology"},
+		{"Calculus\tII", "calculus_ii"},
+	}
+	for _, tc := range testCases {
+		if normalized := NormalizeLabel(tc.label); normalized != tc.expected {
+			t.Errorf("Expecting %q to be normalized to %q, got %q", tc.label, tc.expected, normalized)
+		}
+	}
+}
+
+func TestCanonicalLabel(t *testing.T) {
+	valid := map[string]string{
+		"Biology":             "biology",
+		" Molecular Biology ": "molecular_biology",
+		"calculus-2":          "calculus-2",
+		"CS 101":              "cs_101",
+	}
+	for label, expected := range valid {
+		key, err := CanonicalLabel(label)
+		if err != nil || key != expected {
+			t.Errorf("Expecting %q to become %q, got %q (%v)", label, expected, key, err)
+		}
+	}
+	invalid := []string{"", "  ", "a", "_biology", "biology!", "géographie", "None", " all ", "x" + strings.Repeat("y", 50)}
+	for _, label := range invalid {
+		if _, err := CanonicalLabel(label); err == nil {
+			t.Errorf("Expecting %q to be rejected", label)
+		}
+	}
+}
+
+func TestDisplayLabel(t *testing.T) {
+	if label := DisplayLabel(rulesdb.Rule{RuleType: "cell_biology", DisplayLabel: "Cell Biology"}); label != "Cell Biology" {
+		t.Errorf("Expecting the display label, got %q", label)
+	}
+	if label := DisplayLabel(rulesdb.Rule{RuleType: "cell_biology"}); label != "cell_biology" {
+		t.Errorf("Expecting the key as a fallback, got %q", label)
+	}
+}
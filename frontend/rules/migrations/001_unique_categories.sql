@@ -0,0 +1,25 @@
+-- Unique category names and labels, keeping the oldest category as it is. The
+-- newer categories sharing its label get a label of their own and are flagged
+-- in their name, and those sharing its name are suffixed with their id.
+UPDATE rules SET rule_type = rule_type || '_' || id,
+    rule_name = rule_name || ' (duplicate label ' || id || ')',
+    display_label = ''
+WHERE deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM rules other
+    WHERE other.username = rules.username
+      AND other.deleted_at IS NULL
+      AND other.id < rules.id
+      AND regexp_replace(regexp_replace(lower(other.rule_type), '^\s+|\s+$', '', 'g'), '\s+', '_', 'g') = regexp_replace(regexp_replace(lower(rules.rule_type), '^\s+|\s+$', '', 'g'), '\s+', '_', 'g')
+);
+
+UPDATE rules SET rule_name = rule_name || ' (' || id || ')'
+WHERE deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM rules other
+    WHERE other.username = rules.username
+      AND other.deleted_at IS NULL
+      AND other.id < rules.id
+      AND other.rule_name = rules.rule_name
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS rules_username_rule_name_idx ON rules (username, rule_name) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS rules_username_label_idx ON rules (username, (regexp_replace(regexp_replace(lower(rule_type), '^\s+|\s+$', '', 'g'), '\s+', '_', 'g'))) WHERE deleted_at IS NULL;
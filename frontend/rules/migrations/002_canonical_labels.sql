@@ -0,0 +1,6 @@
+-- Category labels are stored as the key the classifier produces, the label as
+-- typed by the user being kept for display
+UPDATE rules SET display_label = rule_type WHERE display_label = '';
+
+UPDATE rules SET rule_type = regexp_replace(regexp_replace(lower(rule_type), '^\s+|\s+$', '', 'g'), '\s+', '_', 'g')
+WHERE rule_type <> regexp_replace(regexp_replace(lower(rule_type), '^\s+|\s+$', '', 'g'), '\s+', '_', 'g');
@@ -0,0 +1,162 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	_ "embed"
+
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+//go:embed presets.json
+var embeddedPresets []byte
+
+const maxPresetDescriptionLength = 2000
+
+var presetKeyRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Preset is a ready-made category that users can import instead of writing
+// the classifier description themselves. Presets are identified by their key
+// and every change to a preset bumps its version.
+type Preset struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Group       string `json:"group"`
+	Description string `json:"description"`
+	Version     int32  `json:"version"`
+	// Published is true for presets published by an admin, which take
+	// precedence over the ones shipped with the binary
+	Published bool `json:"-"`
+}
+
+// PresetGroup gathers the presets of the same subject area
+type PresetGroup struct {
+	Name    string
+	Presets []Preset
+}
+
+type presetLibrary struct {
+	Version int      `json:"version"`
+	Presets []Preset `json:"presets"`
+}
+
+// EmbeddedPresets returns the presets shipped with the binary
+func EmbeddedPresets() ([]Preset, error) {
+	var library presetLibrary
+	if err := json.Unmarshal(embeddedPresets, &library); err != nil {
+		return nil, err
+	}
+	for _, preset := range library.Presets {
+		if err := ValidatePreset(preset); err != nil {
+			return nil, errors.New("invalid preset " + preset.Key + ": " + err.Error())
+		}
+	}
+	return library.Presets, nil
+}
+
+// ValidatePreset checks that a preset can be imported as a category
+func ValidatePreset(preset Preset) error {
+	if !presetKeyRegex.MatchString(preset.Key) {
+		return errors.New("the key may only contain lowercase letters, digits and dashes")
+	}
+	if strings.TrimSpace(preset.Name) == "" || strings.TrimSpace(preset.Group) == "" {
+		return errors.New("the name and the group are required")
+	}
+	if NormalizeLabel(preset.Label) != preset.Label {
+		return errors.New("the label must be lowercase, without spaces")
+	}
+	if err := ValidateLabelKey(preset.Label); err != nil {
+		return err
+	}
+	if strings.TrimSpace(preset.Description) == "" {
+		return errors.New("the description is required")
+	}
+	if len([]rune(preset.Description)) > maxPresetDescriptionLength {
+		return errors.New("the description is limited to 2000 characters")
+	}
+	if preset.Version < 1 {
+		return errors.New("the version must be positive")
+	}
+	return nil
+}
+
+// PresetFromPublished converts a preset published by an admin
+func PresetFromPublished(published rulesdb.CategoryPreset) Preset {
+	return Preset{
+		Key:         published.PresetKey,
+		Name:        published.Name,
+		Label:       published.Label,
+		Group:       published.GroupName,
+		Description: published.Description,
+		Version:     published.Version,
+		Published:   true,
+	}
+}
+
+// MergePresets combines the embedded presets with the published ones, keeping
+// the highest version of each preset, sorted by group and name
+func MergePresets(embedded []Preset, published []Preset) []Preset {
+	merged := slices.Clone(embedded)
+	for _, preset := range published {
+		idx := slices.IndexFunc(merged, func(p Preset) bool { return p.Key == preset.Key })
+		if idx < 0 {
+			merged = append(merged, preset)
+		} else if preset.Version >= merged[idx].Version {
+			merged[idx] = preset
+		}
+	}
+	slices.SortStableFunc(merged, func(a, b Preset) int {
+		if c := strings.Compare(a.Group, b.Group); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return merged
+}
+
+// GroupPresets splits sorted presets by group
+func GroupPresets(presets []Preset) []PresetGroup {
+	groups := []PresetGroup{}
+	for _, preset := range presets {
+		if len(groups) == 0 || groups[len(groups)-1].Name != preset.Group {
+			groups = append(groups, PresetGroup{Name: preset.Group})
+		}
+		groups[len(groups)-1].Presets = append(groups[len(groups)-1].Presets, preset)
+	}
+	return groups
+}
+
+// FindPreset looks up a preset by key
+func FindPreset(presets []Preset, key string) (Preset, bool) {
+	idx := slices.IndexFunc(presets, func(p Preset) bool { return p.Key == key })
+	if idx < 0 {
+		return Preset{}, false
+	}
+	return presets[idx], true
+}
+
+// NextPresetVersion is the version a preset gets when it is published: one
+// more than the current version of the preset, or 1 for a new preset
+func NextPresetVersion(presets []Preset, key string) int32 {
+	if preset, ok := FindPreset(presets, key); ok {
+		return preset.Version + 1
+	}
+	return 1
+}
+
+// ImportedVersions maps the key of each preset imported by a user to the
+// version of the preset the category was created or last updated from
+func ImportedVersions(presetRules []rulesdb.Rule) map[string]int32 {
+	imported := map[string]int32{}
+	for _, rule := range presetRules {
+		if rule.PresetKey.Valid {
+			imported[rule.PresetKey.String] = rule.PresetVersion.Int32
+		}
+	}
+	return imported
+}
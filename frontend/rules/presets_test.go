@@ -0,0 +1,90 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+func TestEmbeddedPresets(t *testing.T) {
+	presets, err := EmbeddedPresets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presets) == 0 {
+		t.Fatal("Expected some embedded presets")
+	}
+	keys := map[string]bool{}
+	labels := map[string]bool{}
+	for _, preset := range presets {
+		if keys[preset.Key] || labels[preset.Label] {
+			t.Errorf("Duplicate preset %s", preset.Key)
+		}
+		keys[preset.Key] = true
+		labels[preset.Label] = true
+	}
+}
+
+func TestValidatePreset(t *testing.T) {
+	valid := Preset{Key: "cell-biology", Name: "Cell Biology", Label: "cell_biology", Group: "Sciences", Description: "Cells", Version: 1}
+	if err := ValidatePreset(valid); err != nil {
+		t.Errorf("Expected a valid preset, got %v", err)
+	}
+	invalid := []Preset{
+		{Key: "Cell Biology", Name: "Cell Biology", Label: "cell_biology", Group: "Sciences", Description: "Cells", Version: 1},
+		{Key: "cell-biology", Name: "", Label: "cell_biology", Group: "Sciences", Description: "Cells", Version: 1},
+		{Key: "cell-biology", Name: "Cell Biology", Label: "Cell Biology", Group: "Sciences", Description: "Cells", Version: 1},
+		{Key: "cell-biology", Name: "Cell Biology", Label: "cell_biology", Group: "Sciences", Description: " ", Version: 1},
+		{Key: "cell-biology", Name: "Cell Biology", Label: "cell_biology", Group: "Sciences", Description: "Cells", Version: 0},
+	}
+	for _, preset := range invalid {
+		if err := ValidatePreset(preset); err == nil {
+			t.Errorf("Expected %+v to be invalid", preset)
+		}
+	}
+}
+
+func TestMergePresets(t *testing.T) {
+	embedded := []Preset{
+		{Key: "history", Name: "History", Group: "Humanities", Version: 2},
+		{Key: "biology", Name: "Biology", Group: "Sciences", Version: 1},
+	}
+	published := []Preset{
+		{Key: "history", Name: "Old History", Group: "Humanities", Version: 1, Published: true},
+		{Key: "biology", Name: "New Biology", Group: "Sciences", Version: 2, Published: true},
+		{Key: "art", Name: "Art", Group: "Humanities", Version: 1, Published: true},
+	}
+	merged := MergePresets(embedded, published)
+	names := []string{}
+	for _, preset := range merged {
+		names = append(names, preset.Name)
+	}
+	expected := []string{"Art", "History", "New Biology"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, names)
+		}
+	}
+	if NextPresetVersion(merged, "biology") != 3 || NextPresetVersion(merged, "chemistry") != 1 {
+		t.Error("Unexpected next preset version")
+	}
+
+	groups := GroupPresets(merged)
+	if len(groups) != 2 || groups[0].Name != "Humanities" || len(groups[0].Presets) != 2 || groups[1].Name != "Sciences" {
+		t.Errorf("Unexpected groups %+v", groups)
+	}
+}
+
+func TestImportedVersions(t *testing.T) {
+	imported := ImportedVersions([]rulesdb.Rule{
+		{PresetKey: pgtype.Text{String: "biology", Valid: true}, PresetVersion: pgtype.Int4{Int32: 2, Valid: true}},
+		{RuleName: "Custom"},
+	})
+	if len(imported) != 1 || imported["biology"] != 2 {
+		t.Errorf("Unexpected imported versions %v", imported)
+	}
+}
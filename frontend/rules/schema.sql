@@ -5,4 +5,49 @@ CREATE TABLE IF NOT EXISTS rules (
     rule_name TEXT NOT NULL,
     rule_type TEXT NOT NULL,
     rule_description TEXT NOT NULL
-);
\ No newline at end of file
+);
+
+-- Trash bin
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP DEFAULT NULL;
+
+-- Courses grouping categories and notes by semester
+CREATE TABLE IF NOT EXISTS courses (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    name TEXT NOT NULL,
+    semester TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    archived_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (username, name, semester)
+);
+
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS course_id INTEGER DEFAULT NULL REFERENCES courses(id) ON DELETE SET NULL;
+
+-- Category presets published by admins, on top of the ones shipped with the binary
+CREATE TABLE IF NOT EXISTS category_presets (
+    id SERIAL PRIMARY KEY,
+    preset_key TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    label TEXT NOT NULL,
+    group_name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    published_by TEXT NOT NULL,
+    published_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS preset_key TEXT DEFAULT NULL;
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS preset_version INTEGER DEFAULT NULL;
+
+-- Category labels are stored as the key the classifier produces, the label as
+-- typed by the user being kept for display
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS display_label TEXT NOT NULL DEFAULT '';
+
+-- Subcategories
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS parent_id INTEGER DEFAULT NULL REFERENCES rules(id) ON DELETE SET NULL;
+
+-- Data migrations already applied, see rules/migrations
+CREATE TABLE IF NOT EXISTS rules_migrations (
+    name TEXT PRIMARY KEY,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,185 @@
+package rules
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+// TreeEntry is a category placed in the category tree. Path holds the names of
+// its ancestors, from the root down to its parent.
+type TreeEntry struct {
+	Rule  rulesdb.Rule
+	Depth int
+	Path  []string
+}
+
+// ParentOf returns the id of the parent of a category among the given ones.
+// Categories whose parent is missing, for instance because it was moved to
+// the trash, are considered top-level categories.
+func ParentOf(userRules []rulesdb.Rule, rule rulesdb.Rule) int32 {
+	if !rule.ParentID.Valid || rule.ParentID.Int32 == rule.ID {
+		return 0
+	}
+	if !slices.ContainsFunc(userRules, func(r rulesdb.Rule) bool { return r.ID == rule.ParentID.Int32 }) {
+		return 0
+	}
+	return rule.ParentID.Int32
+}
+
+// ChildrenOf returns the categories directly under a parent, or the top-level
+// categories for a parent id of 0, sorted by name
+func ChildrenOf(userRules []rulesdb.Rule, parentId int32) []rulesdb.Rule {
+	children := []rulesdb.Rule{}
+	for _, rule := range userRules {
+		if ParentOf(userRules, rule) == parentId {
+			children = append(children, rule)
+		}
+	}
+	slices.SortStableFunc(children, func(a, b rulesdb.Rule) int {
+		return strings.Compare(strings.ToLower(a.RuleName), strings.ToLower(b.RuleName))
+	})
+	return children
+}
+
+// FlattenTree lists the categories depth-first, each one followed by its
+// subcategories. Categories caught in a cycle are listed at the top level.
+func FlattenTree(userRules []rulesdb.Rule) []TreeEntry {
+	entries := make([]TreeEntry, 0, len(userRules))
+	visited := map[int32]bool{}
+	var walk func(parentId int32, depth int, path []string)
+	walk = func(parentId int32, depth int, path []string) {
+		for _, rule := range ChildrenOf(userRules, parentId) {
+			if visited[rule.ID] {
+				continue
+			}
+			visited[rule.ID] = true
+			entries = append(entries, TreeEntry{Rule: rule, Depth: depth, Path: path})
+			walk(rule.ID, depth+1, append(slices.Clone(path), rule.RuleName))
+		}
+	}
+	walk(0, 0, []string{})
+	for _, rule := range userRules {
+		if !visited[rule.ID] {
+			visited[rule.ID] = true
+			entries = append(entries, TreeEntry{Rule: rule, Path: []string{}})
+			walk(rule.ID, 1, []string{rule.RuleName})
+		}
+	}
+	return entries
+}
+
+// DescendantIds returns the ids of all the categories under a category
+func DescendantIds(userRules []rulesdb.Rule, id int32) []int32 {
+	descendants := []int32{}
+	queue := []int32{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range ChildrenOf(userRules, current) {
+			if child.ID != id && !slices.Contains(descendants, child.ID) {
+				descendants = append(descendants, child.ID)
+				queue = append(queue, child.ID)
+			}
+		}
+	}
+	return descendants
+}
+
+// SubtreeLabels returns the label of the category filed under a label along
+// with the labels of its subcategories, so that filtering on a category also
+// matches the notes of its subcategories
+func SubtreeLabels(userRules []rulesdb.Rule, label string) []string {
+	label = NormalizeLabel(label)
+	labels := []string{label}
+	rule, ok := FindRuleByLabel(userRules, label)
+	if !ok {
+		return labels
+	}
+	for _, id := range DescendantIds(userRules, rule.ID) {
+		idx := slices.IndexFunc(userRules, func(r rulesdb.Rule) bool { return r.ID == id })
+		if childLabel := NormalizeLabel(userRules[idx].RuleType); !slices.Contains(labels, childLabel) {
+			labels = append(labels, childLabel)
+		}
+	}
+	return labels
+}
+
+// SubtreesLabels returns the labels of the subtrees of several categories,
+// each label once
+func SubtreesLabels(userRules []rulesdb.Rule, labels []string) []string {
+	subtrees := []string{}
+	for _, label := range labels {
+		for _, subtreeLabel := range SubtreeLabels(userRules, label) {
+			if !slices.Contains(subtrees, subtreeLabel) {
+				subtrees = append(subtrees, subtreeLabel)
+			}
+		}
+	}
+	return subtrees
+}
+
+// ValidateParent checks that a category can be moved under a parent: the
+// parent must exist and cannot be the category itself or one of its
+// subcategories
+func ValidateParent(userRules []rulesdb.Rule, id int32, parentId int32) error {
+	if parentId == 0 {
+		return nil
+	}
+	if !slices.ContainsFunc(userRules, func(r rulesdb.Rule) bool { return r.ID == parentId }) {
+		return errors.New("the parent category does not exist")
+	}
+	if id != 0 && (parentId == id || slices.Contains(DescendantIds(userRules, id), parentId)) {
+		return errors.New("a category cannot be placed under itself or one of its subcategories")
+	}
+	return nil
+}
+
+// CategorySection is a heading of a list grouped by category. Rule is nil for
+// labels that do not match any category, such as the ones of deleted
+// categories or uncategorized notes.
+type CategorySection struct {
+	Label string
+	Rule  *rulesdb.Rule
+	Depth int
+	Path  []string
+}
+
+// CategorySections orders the labels in use following the category tree. A
+// category is listed when it or one of its subcategories is in use, so that
+// subcategories always appear under their parent. Labels that do not match a
+// category come last, sorted.
+func CategorySections(userRules []rulesdb.Rule, labels []string) []CategorySection {
+	used := map[string]bool{}
+	for _, label := range labels {
+		used[label] = true
+	}
+	sections := []CategorySection{}
+	matched := map[string]bool{}
+	for _, entry := range FlattenTree(userRules) {
+		label := NormalizeLabel(entry.Rule.RuleType)
+		inUse := used[label]
+		for _, id := range DescendantIds(userRules, entry.Rule.ID) {
+			idx := slices.IndexFunc(userRules, func(r rulesdb.Rule) bool { return r.ID == id })
+			inUse = inUse || used[NormalizeLabel(userRules[idx].RuleType)]
+		}
+		if inUse {
+			rule := entry.Rule
+			sections = append(sections, CategorySection{Label: label, Rule: &rule, Depth: entry.Depth, Path: entry.Path})
+			matched[label] = true
+		}
+	}
+	unmatched := []string{}
+	for _, label := range labels {
+		if !matched[label] && !slices.Contains(unmatched, label) {
+			unmatched = append(unmatched, label)
+		}
+	}
+	slices.Sort(unmatched)
+	for _, label := range unmatched {
+		sections = append(sections, CategorySection{Label: label, Path: []string{}})
+	}
+	return sections
+}
@@ -0,0 +1,106 @@
+package rules
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+func parent(id int32) pgtype.Int4 {
+	return pgtype.Int4{Int32: id, Valid: true}
+}
+
+var treeRules = []rulesdb.Rule{
+	{ID: 1, RuleName: "Biology", RuleType: "biology"},
+	{ID: 2, RuleName: "Genetics", RuleType: "genetics", ParentID: parent(1)},
+	{ID: 3, RuleName: "CRISPR", RuleType: "crispr", ParentID: parent(2)},
+	{ID: 4, RuleName: "Ecology", RuleType: "ecology", ParentID: parent(1)},
+	{ID: 5, RuleName: "History", RuleType: "history"},
+	{ID: 6, RuleName: "Orphan", RuleType: "orphan", ParentID: parent(42)},
+}
+
+func TestFlattenTree(t *testing.T) {
+	entries := FlattenTree(treeRules)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Rule.RuleName)
+	}
+	expected := []string{"Biology", "Ecology", "Genetics", "CRISPR", "History", "Orphan"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+	crispr := entries[3]
+	if crispr.Depth != 2 || !slices.Equal(crispr.Path, []string{"Biology", "Genetics"}) {
+		t.Errorf("Unexpected entry %+v", crispr)
+	}
+	if entries[5].Depth != 0 {
+		t.Error("Expected categories with a missing parent to be top-level")
+	}
+
+	cycle := []rulesdb.Rule{
+		{ID: 1, RuleName: "A", ParentID: parent(2)},
+		{ID: 2, RuleName: "B", ParentID: parent(1)},
+	}
+	if len(FlattenTree(cycle)) != 2 {
+		t.Error("Expected categories in a cycle to be listed once")
+	}
+}
+
+func TestSubtreeLabels(t *testing.T) {
+	labels := SubtreeLabels(treeRules, "Biology")
+	slices.Sort(labels)
+	if !slices.Equal(labels, []string{"biology", "crispr", "ecology", "genetics"}) {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+	if labels := SubtreeLabels(treeRules, "unknown_label"); !slices.Equal(labels, []string{"unknown_label"}) {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+}
+
+func TestSubtreesLabels(t *testing.T) {
+	labels := SubtreesLabels(treeRules, []string{"Genetics", "Biology", "History"})
+	if !slices.Equal(labels[:2], []string{"genetics", "crispr"}) || len(labels) != 5 || !slices.Contains(labels, "history") {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+	if labels := SubtreesLabels(treeRules, nil); len(labels) != 0 {
+		t.Errorf("Expected no labels, got %v", labels)
+	}
+}
+
+func TestValidateParent(t *testing.T) {
+	if err := ValidateParent(treeRules, 5, 1); err != nil {
+		t.Errorf("Expected History to be movable under Biology, got %v", err)
+	}
+	if err := ValidateParent(treeRules, 0, 3); err != nil {
+		t.Errorf("Expected a new category to be creatable under CRISPR, got %v", err)
+	}
+	if err := ValidateParent(treeRules, 1, 3); err == nil {
+		t.Error("Expected an error when moving Biology under one of its subcategories")
+	}
+	if err := ValidateParent(treeRules, 1, 1); err == nil {
+		t.Error("Expected an error when moving a category under itself")
+	}
+	if err := ValidateParent(treeRules, 1, 42); err == nil {
+		t.Error("Expected an error for a missing parent")
+	}
+}
+
+func TestCategorySections(t *testing.T) {
+	sections := CategorySections(treeRules, []string{"zoology", "crispr", "", "history"})
+	labels := []string{}
+	for _, section := range sections {
+		labels = append(labels, section.Label)
+	}
+	expected := []string{"biology", "genetics", "crispr", "history", "", "zoology"}
+	if !slices.Equal(labels, expected) {
+		t.Errorf("Expected %v, got %v", expected, labels)
+	}
+	if sections[2].Depth != 2 || sections[2].Rule == nil || sections[2].Rule.RuleName != "CRISPR" {
+		t.Errorf("Unexpected section %+v", sections[2])
+	}
+	if sections[5].Rule != nil {
+		t.Error("Expected labels without a category to have no rule")
+	}
+}
@@ -4,10 +4,41 @@
 
 package rulesdb
 
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type CategoryPreset struct {
+	ID          int32
+	PresetKey   string
+	Name        string
+	Label       string
+	GroupName   string
+	Description string
+	Version     int32
+	PublishedBy string
+	PublishedAt pgtype.Timestamp
+}
+
+type Course struct {
+	ID         int32
+	Username   string
+	Name       string
+	Semester   string
+	CreatedAt  pgtype.Timestamp
+	ArchivedAt pgtype.Timestamp
+}
+
 type Rule struct {
 	ID              int32
 	Username        string
 	RuleName        string
 	RuleType        string
 	RuleDescription string
+	DeletedAt       pgtype.Timestamp
+	CourseID        pgtype.Int4
+	PresetKey       pgtype.Text
+	PresetVersion   pgtype.Int4
+	DisplayLabel    string
+	ParentID        pgtype.Int4
 }
@@ -7,22 +7,125 @@ package rulesdb
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const archiveCourse = `-- name: ArchiveCourse :execrows
+UPDATE courses
+SET archived_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND username = $2 AND archived_at IS NULL
+`
+
+type ArchiveCourseParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) ArchiveCourse(ctx context.Context, arg ArchiveCourseParams) (int64, error) {
+	result, err := q.db.Exec(ctx, archiveCourse,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createCourse = `-- name: CreateCourse :one
+INSERT INTO courses (
+  username, name, semester
+) VALUES (
+  $1, $2, $3
 )
+RETURNING id, username, name, semester, created_at, archived_at
+`
+
+type CreateCourseParams struct {
+	Username string
+	Name     string
+	Semester string
+}
+
+func (q *Queries) CreateCourse(ctx context.Context, arg CreateCourseParams) (Course, error) {
+	row := q.db.QueryRow(ctx, createCourse,
+		arg.Username,
+		arg.Name,
+		arg.Semester,
+	)
+	var i Course
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Name,
+		&i.Semester,
+		&i.CreatedAt,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const createPresetRule = `-- name: CreatePresetRule :one
+INSERT INTO rules (
+  username, rule_name, rule_type, rule_description, preset_key, preset_version
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id
+`
+
+type CreatePresetRuleParams struct {
+	Username        string
+	RuleName        string
+	RuleType        string
+	RuleDescription string
+	PresetKey       pgtype.Text
+	PresetVersion   pgtype.Int4
+}
+
+func (q *Queries) CreatePresetRule(ctx context.Context, arg CreatePresetRuleParams) (Rule, error) {
+	row := q.db.QueryRow(ctx, createPresetRule,
+		arg.Username,
+		arg.RuleName,
+		arg.RuleType,
+		arg.RuleDescription,
+		arg.PresetKey,
+		arg.PresetVersion,
+	)
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RuleName,
+		&i.RuleType,
+		&i.RuleDescription,
+		&i.DeletedAt,
+		&i.CourseID,
+		&i.PresetKey,
+		&i.PresetVersion,
+		&i.DisplayLabel,
+		&i.ParentID,
+	)
+	return i, err
+}
 
 const createRule = `-- name: CreateRule :one
 INSERT INTO rules (
-  username, rule_name, rule_type, rule_description
+  username, rule_name, rule_type, display_label, rule_description, parent_id
 ) VALUES (
-  $1, $2, $3, $4
+  $1, $2, $3, $4, $5, $6
 )
-RETURNING id, username, rule_name, rule_type, rule_description
+RETURNING id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id
 `
 
 type CreateRuleParams struct {
 	Username        string
 	RuleName        string
 	RuleType        string
+	DisplayLabel    string
 	RuleDescription string
+	ParentID        pgtype.Int4
 }
 
 func (q *Queries) CreateRule(ctx context.Context, arg CreateRuleParams) (Rule, error) {
@@ -30,7 +133,9 @@ func (q *Queries) CreateRule(ctx context.Context, arg CreateRuleParams) (Rule, e
 		arg.Username,
 		arg.RuleName,
 		arg.RuleType,
+		arg.DisplayLabel,
 		arg.RuleDescription,
+		arg.ParentID,
 	)
 	var i Rule
 	err := row.Scan(
@@ -39,23 +144,427 @@ func (q *Queries) CreateRule(ctx context.Context, arg CreateRuleParams) (Rule, e
 		&i.RuleName,
 		&i.RuleType,
 		&i.RuleDescription,
+		&i.DeletedAt,
+		&i.CourseID,
+		&i.PresetKey,
+		&i.PresetVersion,
+		&i.DisplayLabel,
+		&i.ParentID,
 	)
 	return i, err
 }
 
-const deleteRule = `-- name: DeleteRule :exec
+const deleteCourse = `-- name: DeleteCourse :execrows
+DELETE FROM courses
+WHERE id = $1 AND username = $2
+`
+
+type DeleteCourseParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) DeleteCourse(ctx context.Context, arg DeleteCourseParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteCourse,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deletePublishedPreset = `-- name: DeletePublishedPreset :execrows
+DELETE FROM category_presets
+WHERE preset_key = $1
+`
+
+func (q *Queries) DeletePublishedPreset(ctx context.Context, presetKey string) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePublishedPreset, presetKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteRule = `-- name: DeleteRule :execrows
 DELETE FROM rules
-WHERE id = $1
+WHERE id = $1 AND username = $2 AND deleted_at IS NOT NULL
 `
 
-func (q *Queries) DeleteRule(ctx context.Context, id int32) error {
-	_, err := q.db.Exec(ctx, deleteRule, id)
-	return err
+type DeleteRuleParams struct {
+	ID       int32
+	Username string
 }
 
-const getRules = `-- name: GetRules :many
-SELECT id, username, rule_name, rule_type, rule_description FROM rules
+func (q *Queries) DeleteRule(ctx context.Context, arg DeleteRuleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRule,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAllRules = `-- name: GetAllRules :many
+SELECT id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id FROM rules
+WHERE username = $1 AND deleted_at IS NULL
+ORDER BY rule_name
+`
+
+func (q *Queries) GetAllRules(ctx context.Context, username string) ([]Rule, error) {
+	rows, err := q.db.Query(ctx, getAllRules, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rule
+	for rows.Next() {
+		var i Rule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RuleName,
+			&i.RuleType,
+			&i.RuleDescription,
+			&i.DeletedAt,
+			&i.CourseID,
+			&i.PresetKey,
+			&i.PresetVersion,
+			&i.DisplayLabel,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getArchivedCourseIds = `-- name: GetArchivedCourseIds :many
+SELECT id FROM courses
+WHERE username = $1 AND archived_at IS NOT NULL
+`
+
+func (q *Queries) GetArchivedCourseIds(ctx context.Context, username string) ([]int32, error) {
+	rows, err := q.db.Query(ctx, getArchivedCourseIds, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getClassificationRules = `-- name: GetClassificationRules :many
+SELECT id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id FROM rules
+WHERE username = $1 AND deleted_at IS NULL
+  AND (course_id IS NULL OR course_id NOT IN (
+    SELECT id FROM courses WHERE archived_at IS NOT NULL
+  ))
+`
+
+func (q *Queries) GetClassificationRules(ctx context.Context, username string) ([]Rule, error) {
+	rows, err := q.db.Query(ctx, getClassificationRules, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rule
+	for rows.Next() {
+		var i Rule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RuleName,
+			&i.RuleType,
+			&i.RuleDescription,
+			&i.DeletedAt,
+			&i.CourseID,
+			&i.PresetKey,
+			&i.PresetVersion,
+			&i.DisplayLabel,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCourse = `-- name: GetCourse :one
+SELECT id, username, name, semester, created_at, archived_at FROM courses
+WHERE id = $1 AND username = $2
+LIMIT 1
+`
+
+type GetCourseParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) GetCourse(ctx context.Context, arg GetCourseParams) (Course, error) {
+	row := q.db.QueryRow(ctx, getCourse,
+		arg.ID,
+		arg.Username,
+	)
+	var i Course
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Name,
+		&i.Semester,
+		&i.CreatedAt,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const getCourseRules = `-- name: GetCourseRules :many
+SELECT id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id FROM rules
+WHERE username = $1 AND course_id = $2::int AND deleted_at IS NULL
+ORDER BY rule_name
+`
+
+type GetCourseRulesParams struct {
+	Username string
+	CourseID int32
+}
+
+func (q *Queries) GetCourseRules(ctx context.Context, arg GetCourseRulesParams) ([]Rule, error) {
+	rows, err := q.db.Query(ctx, getCourseRules,
+		arg.Username,
+		arg.CourseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rule
+	for rows.Next() {
+		var i Rule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RuleName,
+			&i.RuleType,
+			&i.RuleDescription,
+			&i.DeletedAt,
+			&i.CourseID,
+			&i.PresetKey,
+			&i.PresetVersion,
+			&i.DisplayLabel,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCourses = `-- name: GetCourses :many
+SELECT id, username, name, semester, created_at, archived_at FROM courses
 WHERE username = $1
+ORDER BY archived_at IS NOT NULL, semester DESC, name
+`
+
+func (q *Queries) GetCourses(ctx context.Context, username string) ([]Course, error) {
+	rows, err := q.db.Query(ctx, getCourses, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Course
+	for rows.Next() {
+		var i Course
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Name,
+			&i.Semester,
+			&i.CreatedAt,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedRules = `-- name: GetDeletedRules :many
+SELECT id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id FROM rules
+WHERE username = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+`
+
+func (q *Queries) GetDeletedRules(ctx context.Context, username string) ([]Rule, error) {
+	rows, err := q.db.Query(ctx, getDeletedRules, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rule
+	for rows.Next() {
+		var i Rule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RuleName,
+			&i.RuleType,
+			&i.RuleDescription,
+			&i.DeletedAt,
+			&i.CourseID,
+			&i.PresetKey,
+			&i.PresetVersion,
+			&i.DisplayLabel,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPresetRules = `-- name: GetPresetRules :many
+SELECT id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id FROM rules
+WHERE username = $1 AND preset_key IS NOT NULL AND deleted_at IS NULL
+`
+
+func (q *Queries) GetPresetRules(ctx context.Context, username string) ([]Rule, error) {
+	rows, err := q.db.Query(ctx, getPresetRules, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rule
+	for rows.Next() {
+		var i Rule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RuleName,
+			&i.RuleType,
+			&i.RuleDescription,
+			&i.DeletedAt,
+			&i.CourseID,
+			&i.PresetKey,
+			&i.PresetVersion,
+			&i.DisplayLabel,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPublishedPresets = `-- name: GetPublishedPresets :many
+SELECT id, preset_key, name, label, group_name, description, version, published_by, published_at FROM category_presets
+ORDER BY preset_key
+`
+
+func (q *Queries) GetPublishedPresets(ctx context.Context) ([]CategoryPreset, error) {
+	rows, err := q.db.Query(ctx, getPublishedPresets)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CategoryPreset
+	for rows.Next() {
+		var i CategoryPreset
+		if err := rows.Scan(
+			&i.ID,
+			&i.PresetKey,
+			&i.Name,
+			&i.Label,
+			&i.GroupName,
+			&i.Description,
+			&i.Version,
+			&i.PublishedBy,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRule = `-- name: GetRule :one
+SELECT id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id FROM rules
+WHERE id = $1 AND username = $2 AND deleted_at IS NULL
+LIMIT 1
+`
+
+type GetRuleParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) GetRule(ctx context.Context, arg GetRuleParams) (Rule, error) {
+	row := q.db.QueryRow(ctx, getRule,
+		arg.ID,
+		arg.Username,
+	)
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RuleName,
+		&i.RuleType,
+		&i.RuleDescription,
+		&i.DeletedAt,
+		&i.CourseID,
+		&i.PresetKey,
+		&i.PresetVersion,
+		&i.DisplayLabel,
+		&i.ParentID,
+	)
+	return i, err
+}
+
+const getRules = `-- name: GetRules :many
+SELECT id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id FROM rules
+WHERE username = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetRules(ctx context.Context, username string) ([]Rule, error) {
@@ -73,6 +582,12 @@ func (q *Queries) GetRules(ctx context.Context, username string) ([]Rule, error)
 			&i.RuleName,
 			&i.RuleType,
 			&i.RuleDescription,
+			&i.DeletedAt,
+			&i.CourseID,
+			&i.PresetKey,
+			&i.PresetVersion,
+			&i.DisplayLabel,
+			&i.ParentID,
 		); err != nil {
 			return nil, err
 		}
@@ -84,26 +599,268 @@ func (q *Queries) GetRules(ctx context.Context, username string) ([]Rule, error)
 	return items, nil
 }
 
-const updateRule = `-- name: UpdateRule :exec
+const publishPreset = `-- name: PublishPreset :one
+INSERT INTO category_presets (
+  preset_key, name, label, group_name, description, version, published_by
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (preset_key) DO UPDATE
+SET name = EXCLUDED.name,
+    label = EXCLUDED.label,
+    group_name = EXCLUDED.group_name,
+    description = EXCLUDED.description,
+    version = EXCLUDED.version,
+    published_by = EXCLUDED.published_by,
+    published_at = CURRENT_TIMESTAMP
+RETURNING id, preset_key, name, label, group_name, description, version, published_by, published_at
+`
+
+type PublishPresetParams struct {
+	PresetKey   string
+	Name        string
+	Label       string
+	GroupName   string
+	Description string
+	Version     int32
+	PublishedBy string
+}
+
+func (q *Queries) PublishPreset(ctx context.Context, arg PublishPresetParams) (CategoryPreset, error) {
+	row := q.db.QueryRow(ctx, publishPreset,
+		arg.PresetKey,
+		arg.Name,
+		arg.Label,
+		arg.GroupName,
+		arg.Description,
+		arg.Version,
+		arg.PublishedBy,
+	)
+	var i CategoryPreset
+	err := row.Scan(
+		&i.ID,
+		&i.PresetKey,
+		&i.Name,
+		&i.Label,
+		&i.GroupName,
+		&i.Description,
+		&i.Version,
+		&i.PublishedBy,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const purgeDeletedRules = `-- name: PurgeDeletedRules :execrows
+DELETE FROM rules
+WHERE deleted_at IS NOT NULL AND deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedRules(ctx context.Context, deletedAt pgtype.Timestamp) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedRules, deletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreRule = `-- name: RestoreRule :execrows
 UPDATE rules
-SET rule_type = $1,
-    rule_description = $2
-WHERE username = $3 AND rule_name = $4
+SET deleted_at = NULL
+WHERE id = $1 AND username = $2 AND deleted_at IS NOT NULL
+`
+
+type RestoreRuleParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) RestoreRule(ctx context.Context, arg RestoreRuleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreRule,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setRuleCourse = `-- name: SetRuleCourse :one
+UPDATE rules
+SET course_id = $1
+WHERE id = $2 AND username = $3 AND deleted_at IS NULL
+RETURNING id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id
+`
+
+type SetRuleCourseParams struct {
+	CourseID pgtype.Int4
+	ID       int32
+	Username string
+}
+
+func (q *Queries) SetRuleCourse(ctx context.Context, arg SetRuleCourseParams) (Rule, error) {
+	row := q.db.QueryRow(ctx, setRuleCourse,
+		arg.CourseID,
+		arg.ID,
+		arg.Username,
+	)
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RuleName,
+		&i.RuleType,
+		&i.RuleDescription,
+		&i.DeletedAt,
+		&i.CourseID,
+		&i.PresetKey,
+		&i.PresetVersion,
+		&i.DisplayLabel,
+		&i.ParentID,
+	)
+	return i, err
+}
+
+const setRuleParent = `-- name: SetRuleParent :execrows
+UPDATE rules
+SET parent_id = $1
+WHERE id = $2 AND username = $3 AND deleted_at IS NULL
+`
+
+type SetRuleParentParams struct {
+	ParentID pgtype.Int4
+	ID       int32
+	Username string
+}
+
+func (q *Queries) SetRuleParent(ctx context.Context, arg SetRuleParentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setRuleParent,
+		arg.ParentID,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const softDeleteRule = `-- name: SoftDeleteRule :execrows
+UPDATE rules
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND username = $2 AND deleted_at IS NULL
+`
+
+type SoftDeleteRuleParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) SoftDeleteRule(ctx context.Context, arg SoftDeleteRuleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, softDeleteRule,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const unarchiveCourse = `-- name: UnarchiveCourse :execrows
+UPDATE courses
+SET archived_at = NULL
+WHERE id = $1 AND username = $2 AND archived_at IS NOT NULL
+`
+
+type UnarchiveCourseParams struct {
+	ID       int32
+	Username string
+}
+
+func (q *Queries) UnarchiveCourse(ctx context.Context, arg UnarchiveCourseParams) (int64, error) {
+	result, err := q.db.Exec(ctx, unarchiveCourse,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updatePresetRule = `-- name: UpdatePresetRule :execrows
+UPDATE rules
+SET rule_description = $1,
+    preset_version = $2
+WHERE id = $3 AND username = $4 AND deleted_at IS NULL
+`
+
+type UpdatePresetRuleParams struct {
+	RuleDescription string
+	PresetVersion   pgtype.Int4
+	ID              int32
+	Username        string
+}
+
+func (q *Queries) UpdatePresetRule(ctx context.Context, arg UpdatePresetRuleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updatePresetRule,
+		arg.RuleDescription,
+		arg.PresetVersion,
+		arg.ID,
+		arg.Username,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateRule = `-- name: UpdateRule :one
+UPDATE rules
+SET rule_name = $1,
+    rule_type = $2,
+    display_label = $3,
+    rule_description = $4,
+    parent_id = $5
+WHERE id = $6 AND username = $7 AND deleted_at IS NULL
+RETURNING id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id
 `
 
 type UpdateRuleParams struct {
+	RuleName        string
 	RuleType        string
+	DisplayLabel    string
 	RuleDescription string
+	ParentID        pgtype.Int4
+	ID              int32
 	Username        string
-	RuleName        string
 }
 
-func (q *Queries) UpdateRule(ctx context.Context, arg UpdateRuleParams) error {
-	_, err := q.db.Exec(ctx, updateRule,
+func (q *Queries) UpdateRule(ctx context.Context, arg UpdateRuleParams) (Rule, error) {
+	row := q.db.QueryRow(ctx, updateRule,
+		arg.RuleName,
 		arg.RuleType,
+		arg.DisplayLabel,
 		arg.RuleDescription,
+		arg.ParentID,
+		arg.ID,
 		arg.Username,
-		arg.RuleName,
 	)
-	return err
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RuleName,
+		&i.RuleType,
+		&i.RuleDescription,
+		&i.DeletedAt,
+		&i.CourseID,
+		&i.PresetKey,
+		&i.PresetVersion,
+		&i.DisplayLabel,
+		&i.ParentID,
+	)
+	return i, err
 }
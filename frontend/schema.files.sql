@@ -3,5 +3,138 @@ CREATE TABLE files (
     id SERIAL PRIMARY KEY,
     username TEXT NOT NULL,
     file_name TEXT NOT NULL,
-    file_category TEXT DEFAULT NULL
-);
\ No newline at end of file
+    file_category TEXT DEFAULT NULL,
+    uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    file_size BIGINT DEFAULT NULL,
+    page_count INTEGER DEFAULT NULL,
+    mime_type TEXT DEFAULT NULL,
+    llama_cloud_file_id TEXT DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    content TEXT DEFAULT NULL,
+    version_group_id INTEGER DEFAULT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    superseded_at TIMESTAMP DEFAULT NULL,
+    course_id INTEGER DEFAULT NULL
+);
+
+-- Extracted study notes
+CREATE TABLE file_summaries (
+    id SERIAL PRIMARY KEY,
+    file_id INTEGER NOT NULL UNIQUE REFERENCES files(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL
+);
+
+CREATE TABLE file_faqs (
+    id SERIAL PRIMARY KEY,
+    file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    question TEXT NOT NULL,
+    answer TEXT NOT NULL
+);
+
+-- Edit history of the extracted study notes
+CREATE TABLE file_note_edits (
+    id SERIAL PRIMARY KEY,
+    file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    description TEXT NOT NULL,
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- Deleted notes whose search index or LlamaCloud file could not be cleaned up yet
+CREATE TABLE pending_cleanups (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    llama_cloud_file_id TEXT DEFAULT NULL,
+    attempts INTEGER NOT NULL DEFAULT 1,
+    last_error TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Autosaved drafts of the notes being written in the editor
+CREATE TABLE note_drafts (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tags shared by the notes of a user
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    name TEXT NOT NULL,
+    UNIQUE (username, name)
+);
+
+CREATE TABLE file_tags (
+    file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (file_id, tag_id)
+);
+
+-- Categories the results of each search came from, behind the category statistics
+CREATE TABLE search_hits (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    category TEXT NOT NULL,
+    hits INTEGER NOT NULL,
+    best_similarity DOUBLE PRECISION NOT NULL,
+    searched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Searches made by a user, shown in the history of the review page
+CREATE TABLE search_history (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    search_type TEXT NOT NULL,
+    search_input TEXT NOT NULL,
+    file_name TEXT DEFAULT NULL,
+    category TEXT DEFAULT NULL,
+    course_id INTEGER DEFAULT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    result_count INTEGER NOT NULL,
+    searched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    file_names TEXT[] NOT NULL DEFAULT '{}',
+    categories TEXT[] NOT NULL DEFAULT '{}',
+    excluded_file_names TEXT[] NOT NULL DEFAULT '{}',
+    excluded_categories TEXT[] NOT NULL DEFAULT '{}'
+);
+
+-- Search preferences, users opting out of the search history
+CREATE TABLE search_settings (
+    username TEXT PRIMARY KEY,
+    history_enabled BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- Searches pinned by a user, run again on each new note to alert them of new matches
+CREATE TABLE saved_searches (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    search_type TEXT NOT NULL,
+    search_input TEXT NOT NULL,
+    file_name TEXT DEFAULT NULL,
+    category TEXT DEFAULT NULL,
+    course_id INTEGER DEFAULT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    file_names TEXT[] NOT NULL DEFAULT '{}',
+    categories TEXT[] NOT NULL DEFAULT '{}',
+    excluded_file_names TEXT[] NOT NULL DEFAULT '{}',
+    excluded_categories TEXT[] NOT NULL DEFAULT '{}'
+);
+
+-- Notes newly matching a saved search, listed in the notifications of a user
+CREATE TABLE search_alerts (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    saved_search_id INTEGER NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    hits INTEGER NOT NULL,
+    best_similarity DOUBLE PRECISION NOT NULL,
+    excerpt TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (saved_search_id, file_id)
+);
@@ -29,12 +29,12 @@ func NavBar(authenticated bool) templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"bg-success text-success-content px-4 py-1 text-xs flex items-center justify-center gap-2 w-full\"><div class=\"badge badge-xs badge-success\"></div><span>All systems operational</span> <a href=\"https://monitor.palettify.nl/status/studyllama\" class=\"link link-hover underline\">View details</a></div><div class=\"navbar bg-base-100 shadow-sm h-16\"><div class=\"navbar-start\"><div class=\"dropdown\"><div tabindex=\"0\" role=\"button\" class=\"btn btn-ghost btn-circle btn-sm\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"h-5 w-5\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M4 6h16M4 12h16M4 18h7\"></path></svg></div><ul tabindex=\"0\" class=\"menu menu-lg dropdown-content bg-base-100 rounded-box z-[1] mt-3 w-70 p-2 shadow\"><li><a href=\"/\">Home</a></li><li><a href=\"/categories\">Create categories</a></li><li><a href=\"/notes\">Uploads some notes!</a></li><li><a href=\"/review\">Review time :)</a></li><li><a href=\"https://www.loom.com/share/c12d498a62d941d990b3274b41d1d999\">Watch the demo</a></li><li><a href=\"https://monitor.palettify.nl/status/studyllama\">Status Page</a></li></ul></div></div><div class=\"navbar-center\"><a class=\"btn btn-ghost text-lg\" href=\"/\">StudyLlama</a></div><div class=\"navbar-end gap-4\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"bg-success text-success-content px-4 py-1 text-xs flex items-center justify-center gap-2 w-full\"><div class=\"badge badge-xs badge-success\"></div><span>All systems operational</span> <a href=\"https://monitor.palettify.nl/status/studyllama\" class=\"link link-hover underline\">View details</a></div><div class=\"navbar bg-base-100 shadow-sm h-16\"><div class=\"navbar-start\"><div class=\"dropdown\"><div tabindex=\"0\" role=\"button\" class=\"btn btn-ghost btn-circle btn-sm\"><svg xmlns=\"http://www.w3.org/2000/svg\" class=\"h-5 w-5\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M4 6h16M4 12h16M4 18h7\"></path></svg></div><ul tabindex=\"0\" class=\"menu menu-lg dropdown-content bg-base-100 rounded-box z-[1] mt-3 w-70 p-2 shadow\"><li><a href=\"/\">Home</a></li><li><a href=\"/categories\">Create categories</a></li><li><a href=\"/courses\">Courses</a></li><li><a href=\"/notes\">Uploads some notes!</a></li><li><a href=\"/review\">Review time :)</a></li><li><a href=\"/library\">Library overview</a></li><li><a href=\"/notifications\">Notifications</a></li><li><a href=\"/trash\">Trash</a></li><li><a href=\"https://www.loom.com/share/c12d498a62d941d990b3274b41d1d999\">Watch the demo</a></li><li><a href=\"https://monitor.palettify.nl/status/studyllama\">Status Page</a></li></ul></div></div><div class=\"navbar-center\"><a class=\"btn btn-ghost text-lg\" href=\"/\">StudyLlama</a></div><div class=\"navbar-end gap-4\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		if authenticated {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<form hx-post=\"/logout\" hx-trigger=\"submit\"><button class=\"btn btn-secondary bg-gray-700 hover:bg-black text-white shadow-sm border-gray-700 hover:border-black\" type=\"submit\">Log Out</button></form>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<a href=\"/notifications\" class=\"btn btn-ghost btn-circle btn-sm indicator\" title=\"Notifications\"><span hx-get=\"/notifications/count\" hx-trigger=\"load\" hx-swap=\"outerHTML\"></span> <svg xmlns=\"http://www.w3.org/2000/svg\" class=\"h-5 w-5\" fill=\"none\" viewBox=\"0 0 24 24\" stroke=\"currentColor\"><path stroke-linecap=\"round\" stroke-linejoin=\"round\" stroke-width=\"2\" d=\"M15 17h5l-1.405-1.405A2.032 2.032 0 0118 14.158V11a6.002 6.002 0 00-4-5.659V5a2 2 0 10-4 0v.341C7.67 6.165 6 8.388 6 11v3.159c0 .538-.214 1.055-.595 1.436L4 17h5m6 0v1a3 3 0 11-6 0v-1m6 0H9\"></path></svg></a><form hx-post=\"/logout\" hx-trigger=\"submit\"><button class=\"btn btn-secondary bg-gray-700 hover:bg-black text-white shadow-sm border-gray-700 hover:border-black\" type=\"submit\">Log Out</button></form>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -0,0 +1,137 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/agent"
+	"github.com/run-llama/study-llama/frontend/files"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+// maxCleanupAttempts is the number of times a failed cleanup is tried before
+// being left in the pending cleanups for manual inspection
+const maxCleanupAttempts = 10
+
+// cleanupRemote removes a deleted note from the search backend and LlamaCloud.
+// The indexed summary and FAQs are keyed by file name, so they are only
+// removed when no other note of the user has the same name.
+func cleanupRemote(username, fileName string, llamaCloudFileId pgtype.Text, removeIndex bool) error {
+	var errs []error
+	if removeIndex {
+		res, err := agent.ProcessCleanup(agent.CleanupInputEvent{Username: username, FileName: fileName})
+		if err != nil {
+			errs = append(errs, err)
+		} else if errString := res.GetErrorString(); errString != nil {
+			errs = append(errs, errors.New(*errString))
+		}
+	}
+	if llamaCloudFileId.Valid && llamaCloudFileId.String != "" {
+		if err := agent.DeleteLlamaCloudFile(llamaCloudFileId.String); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isNameInUse(ctx context.Context, queries *filesdb.Queries, username, fileName string) (bool, error) {
+	count, err := queries.CountFilesByName(ctx, filesdb.CountFilesByNameParams{Username: username, FileName: fileName})
+	return count > 0, err
+}
+
+// CleanupFile propagates the permanent deletion of a note to the search
+// backend and LlamaCloud. Failures are recorded in the pending cleanups so
+// they can be retried later by RetryCleanups.
+func CleanupFile(ctx context.Context, queries *filesdb.Queries, file filesdb.File) error {
+	inUse, err := isNameInUse(ctx, queries, file.Username, file.FileName)
+	if err != nil {
+		return err
+	}
+	err = cleanupRemote(file.Username, file.FileName, file.LlamaCloudFileID, !inUse)
+	if err == nil {
+		return nil
+	}
+	log.Printf("Error while cleaning up note %q, will retry later: %v", file.FileName, err)
+	return queries.CreatePendingCleanup(ctx, filesdb.CreatePendingCleanupParams{
+		Username:         file.Username,
+		FileName:         file.FileName,
+		LlamaCloudFileID: file.LlamaCloudFileID,
+		LastError:        err.Error(),
+	})
+}
+
+// PurgeFile permanently deletes a trashed note with all of its versions, and
+// cleans up each of them. It returns the number of versions deleted.
+func PurgeFile(ctx context.Context, queries *filesdb.Queries, username string, id int32) (int64, error) {
+	versions, err := queries.DeleteFile(ctx, filesdb.DeleteFileParams{ID: id, Username: username})
+	if err != nil {
+		return 0, err
+	}
+	for _, version := range versions {
+		if err := CleanupFile(ctx, queries, version); err != nil {
+			return int64(len(versions)), err
+		}
+	}
+	return int64(len(versions)), nil
+}
+
+// CleanupUpload deletes a file uploaded to LlamaCloud without becoming a note,
+// such as a sample classified to test the categories. A failed deletion is
+// recorded in the pending cleanups so RetryCleanups tries it again.
+func CleanupUpload(ctx context.Context, username, fileName, llamaCloudFileId string) error {
+	err := agent.DeleteLlamaCloudFile(llamaCloudFileId)
+	if err == nil {
+		return nil
+	}
+	log.Printf("Error while deleting the uploaded file %q, will retry later: %v", fileName, err)
+	db, err := files.CreateNewDb()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close(ctx) }()
+	return filesdb.New(db).CreatePendingCleanup(ctx, filesdb.CreatePendingCleanupParams{
+		Username:         username,
+		FileName:         fileName,
+		LlamaCloudFileID: pgtype.Text{String: llamaCloudFileId, Valid: true},
+		LastError:        err.Error(),
+	})
+}
+
+// CleanupPreviousVersion removes a note about to be replaced by its next
+// version from the search index, unless another note of the user has the same
+// name: the note itself being counted, the name is then used more than once.
+// It reports whether the note was removed, and has to be indexed again if the
+// new version cannot be ingested.
+func CleanupPreviousVersion(ctx context.Context, queries *filesdb.Queries, file filesdb.File) (bool, error) {
+	count, err := queries.CountFilesByName(ctx, filesdb.CountFilesByNameParams{Username: file.Username, FileName: file.FileName})
+	if err != nil || count > 1 {
+		return false, err
+	}
+	return true, cleanupRemote(file.Username, file.FileName, pgtype.Text{}, true)
+}
+
+// RetryCleanups tries again the cleanups that previously failed
+func RetryCleanups(ctx context.Context, queries *filesdb.Queries) error {
+	pending, err := queries.GetPendingCleanups(ctx, maxCleanupAttempts)
+	if err != nil {
+		return err
+	}
+	for _, cleanup := range pending {
+		inUse, err := isNameInUse(ctx, queries, cleanup.Username, cleanup.FileName)
+		if err != nil {
+			return err
+		}
+		err = cleanupRemote(cleanup.Username, cleanup.FileName, cleanup.LlamaCloudFileID, !inUse)
+		if err != nil {
+			err = queries.UpdatePendingCleanup(ctx, filesdb.UpdatePendingCleanupParams{ID: cleanup.ID, LastError: err.Error()})
+		} else {
+			err = queries.DeletePendingCleanup(ctx, cleanup.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
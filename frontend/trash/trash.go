@@ -0,0 +1,77 @@
+package trash
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/files"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+	"github.com/run-llama/study-llama/frontend/rules"
+	"github.com/run-llama/study-llama/frontend/rulesdb"
+)
+
+const defaultRetentionDays = 30
+
+// Retention returns how long deleted notes and categories are kept in the
+// trash, configured in days through TRASH_RETENTION_DAYS
+func Retention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS"))
+	if err != nil || days < 0 {
+		days = defaultRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Purge permanently deletes the notes and categories that have been in the
+// trash for longer than the retention window, and retries the cleanups of
+// deleted notes that previously failed
+func Purge(ctx context.Context, retention time.Duration) error {
+	cutoff := pgtype.Timestamp{Time: time.Now().Add(-retention), Valid: true}
+	filesDb, err := files.CreateNewDb()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = filesDb.Close(ctx) }()
+	filesQueries := filesdb.New(filesDb)
+	purgedFiles, err := filesQueries.PurgeDeletedFiles(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	for _, file := range purgedFiles {
+		if err := CleanupFile(ctx, filesQueries, file); err != nil {
+			return err
+		}
+	}
+	if err := RetryCleanups(ctx, filesQueries); err != nil {
+		return err
+	}
+	rulesDb, err := rules.CreateNewDb()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rulesDb.Close(ctx) }()
+	purgedRules, err := rulesdb.New(rulesDb).PurgeDeletedRules(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	if len(purgedFiles) > 0 || purgedRules > 0 {
+		log.Printf("Purged %d notes and %d categories from the trash", len(purgedFiles), purgedRules)
+	}
+	return nil
+}
+
+// SchedulePurge runs Purge every interval, for as long as the process lives
+func SchedulePurge(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := Purge(context.Background(), Retention()); err != nil {
+			log.Printf("Error while purging the trash: %v", err)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,173 @@
+package trash
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/run-llama/study-llama/frontend/files"
+	"github.com/run-llama/study-llama/frontend/filesdb"
+)
+
+func TestRetention(t *testing.T) {
+	testCases := []struct {
+		env      string
+		expected time.Duration
+	}{
+		{"", 30 * 24 * time.Hour},
+		{"7", 7 * 24 * time.Hour},
+		{"0", 0},
+		{"-1", 30 * 24 * time.Hour},
+		{"a week", 30 * 24 * time.Hour},
+	}
+	for _, tc := range testCases {
+		t.Setenv("TRASH_RETENTION_DAYS", tc.env)
+		if retention := Retention(); retention != tc.expected {
+			t.Errorf("Expecting a retention of %v for %q, got %v", tc.expected, tc.env, retention)
+		}
+	}
+}
+
+func TestPurge(t *testing.T) {
+	if _, ok := os.LookupEnv("POSTGRES_CONNECTION_STRING"); !ok {
+		t.Skip()
+	} else {
+		err := Purge(context.Background(), Retention())
+		if err != nil {
+			t.Errorf("Not expecting an error when purging the trash, got %s", err.Error())
+		}
+	}
+}
+
+func TestCleanupRemote(t *testing.T) {
+	var cleanups, deletions int
+	failWorkflow := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/cleanup":
+			cleanups++
+			if failWorkflow {
+				_, _ = w.Write([]byte(`{"status": "completed", "result": {"value": {"success": false, "error": "vector store unavailable"}}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status": "completed", "result": {"value": {"success": true, "error": null}}}`))
+		case strings.HasPrefix(r.URL.Path, "/api/v1/files/") && r.Method == http.MethodDelete:
+			deletions++
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("CLEANUP_API_ENDPOINT", server.URL+"/cleanup")
+	t.Setenv("LLAMA_CLOUD_BASE_URL", server.URL)
+	fileId := pgtype.Text{String: "file-1", Valid: true}
+
+	if err := cleanupRemote("testuser", "notes.pdf", fileId, true); err != nil {
+		t.Fatalf("Not expecting an error when cleaning up, got %s", err.Error())
+	}
+	if cleanups != 1 || deletions != 1 {
+		t.Errorf("Expecting one cleanup and one deletion, got %d and %d", cleanups, deletions)
+	}
+
+	if err := cleanupRemote("testuser", "notes.pdf", pgtype.Text{}, false); err != nil {
+		t.Fatalf("Not expecting an error when there is nothing to clean up, got %s", err.Error())
+	}
+	if cleanups != 1 || deletions != 1 {
+		t.Errorf("Expecting no calls when there is nothing to clean up, got %d and %d", cleanups, deletions)
+	}
+
+	failWorkflow = true
+	err := cleanupRemote("testuser", "notes.pdf", fileId, true)
+	if err == nil || !strings.Contains(err.Error(), "vector store unavailable") {
+		t.Errorf("Expecting the backend error to be reported, got %v", err)
+	}
+	if deletions != 2 {
+		t.Errorf("Expecting the LlamaCloud file to be deleted even when the backend fails, got %d deletions", deletions)
+	}
+}
+
+func TestPurgeFileVersions(t *testing.T) {
+	if _, ok := os.LookupEnv("POSTGRES_CONNECTION_STRING"); !ok {
+		t.Skip()
+	}
+	deleted := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/cleanup":
+			_, _ = w.Write([]byte(`{"status": "completed", "result": {"value": {"success": true, "error": null}}}`))
+		case strings.HasPrefix(r.URL.Path, "/api/v1/files/") && r.Method == http.MethodDelete:
+			deleted[strings.TrimPrefix(r.URL.Path, "/api/v1/files/")] = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("CLEANUP_API_ENDPOINT", server.URL+"/cleanup")
+	t.Setenv("LLAMA_CLOUD_BASE_URL", server.URL)
+
+	ctx := context.Background()
+	db, err := files.CreateNewDb()
+	if err != nil {
+		t.Fatalf("Not expecting an error when creating a new database instance, got %s", err.Error())
+	}
+	defer func() { _ = db.Close(ctx) }()
+	queries := filesdb.New(db)
+	username := "purge-versions-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	var versions []filesdb.File
+	for _, llamaCloudFileId := range []string{"version-1", "version-2"} {
+		var file filesdb.File
+		err := db.QueryRow(ctx, "INSERT INTO files (username, file_name, llama_cloud_file_id) VALUES ($1, 'notes.pdf', $2) RETURNING id, version", username, llamaCloudFileId).Scan(&file.ID, &file.Version)
+		if err != nil {
+			t.Fatalf("Not expecting an error when creating a note, got %s", err.Error())
+		}
+		versions = append(versions, file)
+	}
+	if err := files.LinkNewVersion(ctx, db, versions[0], versions[1]); err != nil {
+		t.Fatalf("Not expecting an error when linking the versions, got %s", err.Error())
+	}
+	trashed, err := queries.SoftDeleteFile(ctx, filesdb.SoftDeleteFileParams{ID: versions[1].ID, Username: username})
+	if err != nil || trashed != 2 {
+		t.Fatalf("Expecting both versions to be trashed, got %d and %v", trashed, err)
+	}
+	if deletedFiles, err := queries.GetDeletedFiles(ctx, username); err != nil || len(deletedFiles) != 1 || deletedFiles[0].ID != versions[1].ID {
+		t.Errorf("Expecting the trash to list the latest version only, got %+v and %v", deletedFiles, err)
+	}
+
+	purged, err := PurgeFile(ctx, queries, username, versions[1].ID)
+	if err != nil || purged != 2 {
+		t.Fatalf("Expecting both versions to be purged, got %d and %v", purged, err)
+	}
+	var remaining int
+	if err := db.QueryRow(ctx, "SELECT COUNT(*) FROM files WHERE username = $1", username).Scan(&remaining); err != nil || remaining != 0 {
+		t.Errorf("Expecting no version left, got %d and %v", remaining, err)
+	}
+	if !deleted["version-1"] || !deleted["version-2"] {
+		t.Errorf("Expecting the LlamaCloud files of both versions to be deleted, got %v", deleted)
+	}
+}
+
+func TestCleanupUpload(t *testing.T) {
+	var deletions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deletions = append(deletions, strings.TrimPrefix(r.URL.Path, "/api/v1/files/"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Setenv("LLAMA_CLOUD_BASE_URL", server.URL)
+	if err := CleanupUpload(context.Background(), "testuser", "sample.txt", "file-1"); err != nil {
+		t.Fatalf("Not expecting an error when deleting the upload, got %s", err.Error())
+	}
+	if len(deletions) != 1 || deletions[0] != "file-1" {
+		t.Errorf("Expecting the uploaded file to be deleted, got %v", deletions)
+	}
+}
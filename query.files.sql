@@ -1,7 +1,23 @@
 -- name: CreateFile :one
 INSERT INTO files (
-  username, file_name, file_category
+  username, file_name, file_category, llama_cloud_file_id
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 )
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetTaggedFileNames :many
+SELECT DISTINCT files.file_name FROM files
+JOIN file_tags ON file_tags.file_id = files.id
+JOIN tags ON tags.id = file_tags.tag_id
+WHERE files.username = sqlc.arg(username)
+  AND files.deleted_at IS NULL
+  AND files.superseded_at IS NULL
+  AND tags.name = ANY(CAST(sqlc.arg(tags) AS TEXT[]));
+
+-- name: GetCourseFileNames :many
+SELECT DISTINCT file_name FROM files
+WHERE username = sqlc.arg(username)
+  AND deleted_at IS NULL
+  AND superseded_at IS NULL
+  AND course_id = ANY(CAST(sqlc.arg(course_ids) AS INTEGER[]));
\ No newline at end of file
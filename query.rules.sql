@@ -1,3 +1,6 @@
 -- name: GetRules :many
 SELECT * FROM rules
-WHERE username = $1;
\ No newline at end of file
+WHERE username = $1 AND deleted_at IS NULL
+  AND (course_id IS NULL OR course_id NOT IN (
+    SELECT id FROM courses WHERE archived_at IS NOT NULL
+  ));
\ No newline at end of file
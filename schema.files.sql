@@ -3,5 +3,30 @@ CREATE TABLE files (
     id SERIAL PRIMARY KEY,
     username TEXT NOT NULL,
     file_name TEXT NOT NULL,
-    file_category TEXT DEFAULT NULL
-);
\ No newline at end of file
+    file_category TEXT DEFAULT NULL,
+    uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    file_size BIGINT DEFAULT NULL,
+    page_count INTEGER DEFAULT NULL,
+    mime_type TEXT DEFAULT NULL,
+    llama_cloud_file_id TEXT DEFAULT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    content TEXT DEFAULT NULL,
+    version_group_id INTEGER DEFAULT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    superseded_at TIMESTAMP DEFAULT NULL,
+    course_id INTEGER DEFAULT NULL
+);
+
+-- Tags shared by the notes of a user
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    name TEXT NOT NULL,
+    UNIQUE (username, name)
+);
+
+CREATE TABLE file_tags (
+    file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (file_id, tag_id)
+);
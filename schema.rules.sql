@@ -1,8 +1,41 @@
+-- Courses table
+CREATE TABLE courses (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    name TEXT NOT NULL,
+    semester TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    archived_at TIMESTAMP DEFAULT NULL,
+    UNIQUE (username, name, semester)
+);
+
 -- Rules table
 CREATE TABLE rules (
     id SERIAL PRIMARY KEY,
     username TEXT NOT NULL,
     rule_name TEXT NOT NULL,
     rule_type TEXT NOT NULL,
-    rule_description TEXT NOT NULL
+    rule_description TEXT NOT NULL,
+    deleted_at TIMESTAMP DEFAULT NULL,
+    course_id INTEGER DEFAULT NULL REFERENCES courses(id) ON DELETE SET NULL,
+    preset_key TEXT DEFAULT NULL,
+    preset_version INTEGER DEFAULT NULL,
+    display_label TEXT NOT NULL DEFAULT '',
+    parent_id INTEGER DEFAULT NULL REFERENCES rules(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX rules_username_rule_name_idx ON rules (username, rule_name) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX rules_username_label_idx ON rules (username, (regexp_replace(regexp_replace(lower(rule_type), '^\s+|\s+$', '', 'g'), '\s+', '_', 'g'))) WHERE deleted_at IS NULL;
+
+-- Category presets table
+CREATE TABLE category_presets (
+    id SERIAL PRIMARY KEY,
+    preset_key TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    label TEXT NOT NULL,
+    group_name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    published_by TEXT NOT NULL,
+    published_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
\ No newline at end of file
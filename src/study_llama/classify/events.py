@@ -0,0 +1,22 @@
+from pydantic import BaseModel
+from workflows.events import StartEvent, StopEvent
+
+
+class DraftRule(BaseModel):
+    rule_type: str
+    rule_description: str
+    id: int | None = None
+    parent_id: int | None = None
+
+
+class ClassifyInputEvent(StartEvent):
+    file_id: str
+    rules: list[DraftRule]
+
+
+class ClassifyOutputEvent(StopEvent):
+    success: bool
+    error: str | None = None
+    category: str | None = None
+    confidence: float | None = None
+    reasoning: str | None = None
@@ -0,0 +1,39 @@
+from workflows import Workflow, step
+from workflows.resource import Resource
+from typing import Annotated
+from llama_cloud_services.beta.classifier.client import LlamaClassify
+from study_llama.classify_and_extract.resources import get_llama_classify
+from study_llama.classify_and_extract.utils import classify_file_hierarchically
+from .events import ClassifyInputEvent, ClassifyOutputEvent
+
+
+class ClassifyWorkflow(Workflow):
+    @step
+    async def classify(
+        self,
+        ev: ClassifyInputEvent,
+        classifier: Annotated[LlamaClassify, Resource(get_llama_classify)],
+    ) -> ClassifyOutputEvent:
+        if len(ev.rules) == 0:
+            return ClassifyOutputEvent(
+                success=False, error="At least one category is needed to classify"
+            )
+        try:
+            classification = await classify_file_hierarchically(
+                classifier, ev.rules, ev.file_id
+            )
+        except Exception as e:
+            return ClassifyOutputEvent(success=False, error=str(e))
+        if classification is not None:
+            return ClassifyOutputEvent(
+                success=True,
+                category=classification.category,
+                confidence=classification.confidence,
+                reasoning=classification.reasoning,
+            )
+        return ClassifyOutputEvent(
+            success=False,
+            error="It was not possible to classify the provided note based on these categories",
+        )
+
+workflow = ClassifyWorkflow(timeout=600)
@@ -23,3 +23,7 @@ class ExtractedFileEvent(Event):
 class IngestedFileEvent(StopEvent):
     success: bool
     error: str | None = None
+    summary: str | None = None
+    faqs: list[QuestionAndAnswer] = []
+
+    model_config = ConfigDict(arbitrary_types_allowed=True)
@@ -1,15 +1,100 @@
 import re
+from dataclasses import dataclass
+from typing import Protocol, Sequence, TypeVar
 from llama_cloud.types.classifier_rule import ClassifierRule
-from study_llama.rulesdb.models import Rule
+from llama_cloud_services.beta.classifier.client import LlamaClassify
 
 
-def rules_to_classify_rules(rules: list[Rule]) -> list[ClassifierRule]:
+class ClassifyRule(Protocol):
+    rule_type: str
+    rule_description: str
+
+
+class TreeRule(ClassifyRule, Protocol):
+    id: int | None
+    parent_id: int | None
+
+
+R = TypeVar("R", bound=TreeRule)
+
+
+@dataclass
+class Classification:
+    category: str
+    confidence: float | None = None
+    reasoning: str | None = None
+
+
+def rule_key(rule: ClassifyRule) -> str:
+    return re.sub(r"\s+", "_", rule.rule_type.lower().strip())
+
+
+def rules_to_classify_rules(rules: Sequence[ClassifyRule]) -> list[ClassifierRule]:
     class_rules: list[ClassifierRule] = []
     for rule in rules:
         class_rules.append(
             ClassifierRule(
-                type=re.sub(r"\s+", "_", rule.rule_type.lower().strip()),
+                type=rule_key(rule),
                 description=rule.rule_description,
             )
         )
     return class_rules
+
+
+def children_of(rules: Sequence[R], parent_id: int | None) -> list[R]:
+    """Returns the rules directly under a parent, or the top-level rules when parent_id is None.
+    Rules whose parent is missing (e.g. trashed or archived) are considered top-level."""
+    ids = {rule.id for rule in rules if rule.id is not None}
+
+    def parent_of(rule: R) -> int | None:
+        if rule.parent_id is None or rule.parent_id == rule.id or rule.parent_id not in ids:
+            return None
+        return rule.parent_id
+
+    return [rule for rule in rules if parent_of(rule) == parent_id]
+
+
+async def classify_file(
+    classifier: LlamaClassify, rules: Sequence[ClassifyRule], file_id: str
+) -> Classification | None:
+    result = await classifier.aclassify_file_ids(
+        rules=rules_to_classify_rules(rules=rules), file_ids=[file_id]
+    )
+    for item in result.items:
+        if (class_res := item.result) is not None and class_res.type is not None:
+            return Classification(
+                category=class_res.type,
+                confidence=class_res.confidence,
+                reasoning=class_res.reasoning,
+            )
+    return None
+
+
+async def classify_file_hierarchically(
+    classifier: LlamaClassify, rules: Sequence[R], file_id: str
+) -> Classification | None:
+    """Classifies a file among the top-level categories first, then among the
+    subcategories of the chosen category, until a leaf is reached. At each
+    level the chosen category competes with its subcategories, so that a file
+    that fits none of them stays in the parent category."""
+    level: list[R] = children_of(rules, None)
+    selected: R | None = None
+    classification: Classification | None = None
+    visited: set[int] = set()
+    while level:
+        candidates: list[R] = level if selected is None else [*level, selected]
+        match = await classify_file(classifier, candidates, file_id)
+        if match is None:
+            break
+        chosen = next((rule for rule in candidates if rule_key(rule) == match.category), None)
+        if chosen is None or chosen is selected:
+            if classification is None:
+                classification = match
+            break
+        selected = chosen
+        classification = match
+        if chosen.id is None or chosen.id in visited:
+            break
+        visited.add(chosen.id)
+        level = children_of(rules, chosen.id)
+    return classification
@@ -20,7 +20,7 @@ from .resources import (
     get_vector_db_summaries,
 )
 from .models import StudyNotes, WorkflowState, EXTRACT_CONFIG
-from .utils import rules_to_classify_rules
+from .utils import classify_file_hierarchically
 from llama_cloud_services.beta.classifier.client import LlamaClassify
 from llama_cloud_services.extract import LlamaExtract
 from study_llama.vectordb.vectordb import SummaryVectorDB, FaqsVectorDB
@@ -40,16 +40,12 @@ class ClassifyExtractWorkflow(Workflow):
             rules = []
             async for rule in response:
                 rules.append(rule)
-            class_rules = rules_to_classify_rules(rules=rules)
-            result = await classifier.aclassify_file_ids(
-                rules=class_rules, file_ids=[ev.file_id]
+            classification = await classify_file_hierarchically(
+                classifier, rules, ev.file_id
             )
             file_type: str | None = None
-            for item in result.items:
-                if (class_res := item.result) is not None:
-                    if class_res.type is not None:
-                        file_type = class_res.type
-                        break
+            if classification is not None:
+                file_type = classification.category
             if file_type is not None:
                 async with ctx.store.edit_state() as state:
                     state.file_name = ev.file_name
@@ -61,6 +57,7 @@ class ClassifyExtractWorkflow(Workflow):
                     username=ev.username,
                     file_name=ev.file_name,
                     file_category=file_type,
+                    llama_cloud_file_id=ev.file_id,
                 )
                 await db_conn.commit()
                 return ClassifiedFileEvent(file_type=file_type)
@@ -124,7 +121,7 @@ class ClassifyExtractWorkflow(Workflow):
         await summaries_vdb.upload(
             ev.summary, state.username, state.file_type, state.file_name
         )
-        return IngestedFileEvent(success=True)
+        return IngestedFileEvent(success=True, summary=ev.summary, faqs=ev.faqs)
 
 
 workflow = ClassifyExtractWorkflow(timeout=1000)
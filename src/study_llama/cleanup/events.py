@@ -0,0 +1,11 @@
+from workflows.events import StartEvent, StopEvent
+
+
+class CleanupInputEvent(StartEvent):
+    username: str
+    file_name: str
+
+
+class CleanupOutputEvent(StopEvent):
+    success: bool
+    error: str | None = None
@@ -0,0 +1,25 @@
+from workflows import Workflow, step
+from workflows.resource import Resource
+from typing import Annotated
+from study_llama.search.resources import get_vector_db_faqs, get_vector_db_summaries
+from study_llama.vectordb.vectordb import SummaryVectorDB, FaqsVectorDB
+from .events import CleanupInputEvent, CleanupOutputEvent
+
+
+class CleanupWorkflow(Workflow):
+    @step
+    async def cleanup(
+        self,
+        ev: CleanupInputEvent,
+        summaries_vdb: Annotated[SummaryVectorDB, Resource(get_vector_db_summaries)],
+        faqs_vdb: Annotated[FaqsVectorDB, Resource(get_vector_db_faqs)],
+    ) -> CleanupOutputEvent:
+        try:
+            await summaries_vdb.delete(ev.username, ev.file_name)
+            await faqs_vdb.delete(ev.username, ev.file_name)
+        except Exception as e:
+            return CleanupOutputEvent(success=False, error=str(e))
+        return CleanupOutputEvent(success=True)
+
+
+workflow = CleanupWorkflow(timeout=600)
@@ -1,6 +1,7 @@
 # Code generated by sqlc. DO NOT EDIT.
 # versions:
 #   sqlc v1.30.0
+import datetime
 import pydantic
 from typing import Optional
 
@@ -10,3 +11,25 @@ class File(pydantic.BaseModel):
     username: str
     file_name: str
     file_category: Optional[str]
+    uploaded_at: Optional[datetime.datetime]
+    file_size: Optional[int]
+    page_count: Optional[int]
+    mime_type: Optional[str]
+    llama_cloud_file_id: Optional[str]
+    deleted_at: Optional[datetime.datetime]
+    content: Optional[str]
+    version_group_id: Optional[int]
+    version: int
+    superseded_at: Optional[datetime.datetime]
+    course_id: Optional[int]
+
+
+class FileTag(pydantic.BaseModel):
+    file_id: int
+    tag_id: int
+
+
+class Tag(pydantic.BaseModel):
+    id: int
+    username: str
+    name: str
@@ -2,7 +2,7 @@
 # versions:
 #   sqlc v1.30.0
 # source: query.files.sql
-from typing import Optional
+from typing import AsyncIterator, List, Optional
 
 import sqlalchemy
 import sqlalchemy.ext.asyncio
@@ -12,11 +12,31 @@ from study_llama.filesdb import models
 
 CREATE_FILE = """-- name: create_file \\:one
 INSERT INTO files (
-  username, file_name, file_category
+  username, file_name, file_category, llama_cloud_file_id
 ) VALUES (
-  :p1, :p2, :p3
+  :p1, :p2, :p3, :p4
 )
-RETURNING id, username, file_name, file_category
+RETURNING id, username, file_name, file_category, uploaded_at, file_size, page_count, mime_type, llama_cloud_file_id, deleted_at, content, version_group_id, version, superseded_at, course_id
+"""
+
+
+GET_COURSE_FILE_NAMES = """-- name: get_course_file_names \\:many
+SELECT DISTINCT file_name FROM files
+WHERE username = :p1
+  AND deleted_at IS NULL
+  AND superseded_at IS NULL
+  AND course_id = ANY(CAST(:p2 AS INTEGER[]))
+"""
+
+
+GET_TAGGED_FILE_NAMES = """-- name: get_tagged_file_names \\:many
+SELECT DISTINCT files.file_name FROM files
+JOIN file_tags ON file_tags.file_id = files.id
+JOIN tags ON tags.id = file_tags.tag_id
+WHERE files.username = :p1
+  AND files.deleted_at IS NULL
+  AND files.superseded_at IS NULL
+  AND tags.name = ANY(CAST(:p2 AS TEXT[]))
 """
 
 
@@ -25,12 +45,22 @@ class AsyncQuerier:
         self._conn = conn
 
     async def create_file(
-        self, *, username: str, file_name: str, file_category: Optional[str]
+        self,
+        *,
+        username: str,
+        file_name: str,
+        file_category: Optional[str],
+        llama_cloud_file_id: Optional[str],
     ) -> Optional[models.File]:
         row = (
             await self._conn.execute(
                 sqlalchemy.text(CREATE_FILE),
-                {"p1": username, "p2": file_name, "p3": file_category},
+                {
+                    "p1": username,
+                    "p2": file_name,
+                    "p3": file_category,
+                    "p4": llama_cloud_file_id,
+                },
             )
         ).first()
         if row is None:
@@ -40,4 +70,33 @@ class AsyncQuerier:
             username=row[1],
             file_name=row[2],
             file_category=row[3],
+            uploaded_at=row[4],
+            file_size=row[5],
+            page_count=row[6],
+            mime_type=row[7],
+            llama_cloud_file_id=row[8],
+            deleted_at=row[9],
+            content=row[10],
+            version_group_id=row[11],
+            version=row[12],
+            superseded_at=row[13],
+            course_id=row[14],
+        )
+
+    async def get_course_file_names(
+        self, *, username: str, course_ids: List[int]
+    ) -> AsyncIterator[str]:
+        result = await self._conn.stream(
+            sqlalchemy.text(GET_COURSE_FILE_NAMES), {"p1": username, "p2": course_ids}
+        )
+        async for row in result:
+            yield row[0]
+
+    async def get_tagged_file_names(
+        self, *, username: str, tags: List[str]
+    ) -> AsyncIterator[str]:
+        result = await self._conn.stream(
+            sqlalchemy.text(GET_TAGGED_FILE_NAMES), {"p1": username, "p2": tags}
         )
+        async for row in result:
+            yield row[0]
@@ -0,0 +1,18 @@
+from workflows.events import StartEvent, StopEvent
+from pydantic import ConfigDict
+from study_llama.classify_and_extract.models import QuestionAndAnswer
+
+
+class ReindexInputEvent(StartEvent):
+    username: str
+    file_name: str
+    category: str
+    summary: str
+    faqs: list[QuestionAndAnswer]
+
+    model_config = ConfigDict(arbitrary_types_allowed=True)
+
+
+class ReindexOutputEvent(StopEvent):
+    success: bool
+    error: str | None = None
@@ -0,0 +1,37 @@
+from workflows import Workflow, step
+from workflows.resource import Resource
+from typing import Annotated
+from study_llama.search.resources import get_vector_db_faqs, get_vector_db_summaries
+from study_llama.vectordb.vectordb import SummaryVectorDB, FaqsVectorDB
+from .events import ReindexInputEvent, ReindexOutputEvent
+
+
+class ReindexWorkflow(Workflow):
+    @step
+    async def reindex(
+        self,
+        ev: ReindexInputEvent,
+        summaries_vdb: Annotated[SummaryVectorDB, Resource(get_vector_db_summaries)],
+        faqs_vdb: Annotated[FaqsVectorDB, Resource(get_vector_db_faqs)],
+    ) -> ReindexOutputEvent:
+        try:
+            await summaries_vdb.delete(ev.username, ev.file_name)
+            await faqs_vdb.delete(ev.username, ev.file_name)
+            if ev.summary:
+                await summaries_vdb.upload(
+                    ev.summary, ev.username, ev.category, ev.file_name
+                )
+            if len(ev.faqs) > 0:
+                await faqs_vdb.upload(
+                    [faq.question for faq in ev.faqs],
+                    [faq.answer for faq in ev.faqs],
+                    ev.username,
+                    ev.category,
+                    ev.file_name,
+                )
+        except Exception as e:
+            return ReindexOutputEvent(success=False, error=str(e))
+        return ReindexOutputEvent(success=True)
+
+
+workflow = ReindexWorkflow(timeout=600)
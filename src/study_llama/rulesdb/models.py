@@ -1,7 +1,30 @@
 # Code generated by sqlc. DO NOT EDIT.
 # versions:
 #   sqlc v1.30.0
+import datetime
 import pydantic
+from typing import Optional
+
+
+class CategoryPreset(pydantic.BaseModel):
+    id: int
+    preset_key: str
+    name: str
+    label: str
+    group_name: str
+    description: str
+    version: int
+    published_by: str
+    published_at: Optional[datetime.datetime]
+
+
+class Course(pydantic.BaseModel):
+    id: int
+    username: str
+    name: str
+    semester: str
+    created_at: Optional[datetime.datetime]
+    archived_at: Optional[datetime.datetime]
 
 
 class Rule(pydantic.BaseModel):
@@ -10,3 +33,9 @@ class Rule(pydantic.BaseModel):
     rule_name: str
     rule_type: str
     rule_description: str
+    deleted_at: Optional[datetime.datetime]
+    course_id: Optional[int]
+    preset_key: Optional[str]
+    preset_version: Optional[int]
+    display_label: str
+    parent_id: Optional[int]
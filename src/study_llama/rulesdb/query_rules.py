@@ -11,8 +11,11 @@ from study_llama.rulesdb import models
 
 
 GET_RULES = """-- name: get_rules \\:many
-SELECT id, username, rule_name, rule_type, rule_description FROM rules
-WHERE username = :p1
+SELECT id, username, rule_name, rule_type, rule_description, deleted_at, course_id, preset_key, preset_version, display_label, parent_id FROM rules
+WHERE username = :p1 AND deleted_at IS NULL
+  AND (course_id IS NULL OR course_id NOT IN (
+    SELECT id FROM courses WHERE archived_at IS NOT NULL
+  ))
 """
 
 
@@ -29,4 +32,10 @@ class AsyncQuerier:
                 rule_name=row[2],
                 rule_type=row[3],
                 rule_description=row[4],
+                deleted_at=row[5],
+                course_id=row[6],
+                preset_key=row[7],
+                preset_version=row[8],
+                display_label=row[9],
+                parent_id=row[10],
             )
@@ -10,9 +10,19 @@ class SearchInputEvent(StartEvent):
     username: str
     file_name: str | None = None
     category: str | None = None
+    file_names: list[str] | None = None
+    categories: list[str] | None = None
+    tags: list[str] | None = None
+    course_id: int | None = None
+    excluded_file_names: list[str] | None = None
+    excluded_categories: list[str] | None = None
+    top_k: int = 10
+    min_similarity: float = 0.75
+    offset: int = 0
 
 
 class SearchOutputEvent(StopEvent):
     results: list[Result]
+    offset: int | None = None
 
     model_config = ConfigDict(arbitrary_types_allowed=True)
@@ -4,6 +4,30 @@ from typing import Annotated, TYPE_CHECKING
 from .resources import get_vector_db_faqs, get_vector_db_summaries
 from .events import SearchInputEvent, SearchOutputEvent
 from study_llama.vectordb.vectordb import SummaryVectorDB, FaqsVectorDB
+from study_llama.classify_and_extract.resources import get_db_conn
+from study_llama.filesdb.query_files import AsyncQuerier as AsyncFilesQuerier
+
+
+async def get_course_file_names(username: str, course_id: int) -> list[str]:
+    async with get_db_conn() as db_conn:
+        querier = AsyncFilesQuerier(conn=db_conn)
+        return [
+            file_name
+            async for file_name in querier.get_course_file_names(
+                username=username, course_ids=[course_id]
+            )
+        ]
+
+
+async def get_tagged_file_names(username: str, tags: list[str]) -> list[str]:
+    async with get_db_conn() as db_conn:
+        querier = AsyncFilesQuerier(conn=db_conn)
+        return [
+            file_name
+            async for file_name in querier.get_tagged_file_names(
+                username=username, tags=tags
+            )
+        ]
 
 
 class SearchWorkflow(Workflow):
@@ -14,16 +38,51 @@ class SearchWorkflow(Workflow):
         summaries_vdb: Annotated[SummaryVectorDB, Resource(get_vector_db_summaries)],
         faqs_vdb: Annotated[FaqsVectorDB, Resource(get_vector_db_faqs)],
     ) -> SearchOutputEvent:
+        file_names = ev.file_names or None
+        if ev.tags:
+            tagged_file_names = await get_tagged_file_names(ev.username, ev.tags)
+            if file_names is None:
+                file_names = tagged_file_names
+            else:
+                file_names = [name for name in file_names if name in tagged_file_names]
+        if ev.course_id is not None:
+            course_file_names = await get_course_file_names(ev.username, ev.course_id)
+            if file_names is None:
+                file_names = course_file_names
+            else:
+                file_names = [name for name in file_names if name in course_file_names]
+        if file_names is not None and not file_names:
+            return SearchOutputEvent(results=[], offset=ev.offset)
         if ev.search_type == "faqs":
             results = await faqs_vdb.search(
-                ev.search_input, ev.username, ev.category, ev.file_name
+                ev.search_input,
+                ev.username,
+                ev.category,
+                ev.file_name,
+                file_names,
+                ev.categories or None,
+                excluded_file_names=ev.excluded_file_names or None,
+                excluded_categories=ev.excluded_categories or None,
+                limit=ev.top_k,
+                offset=ev.offset,
+                min_similarity=ev.min_similarity,
             )
-            return SearchOutputEvent(results=results)
+            return SearchOutputEvent(results=results, offset=ev.offset)
         else:
             results = await summaries_vdb.search(
-                ev.search_input, ev.username, ev.category, ev.file_name
+                ev.search_input,
+                ev.username,
+                ev.category,
+                ev.file_name,
+                file_names,
+                ev.categories or None,
+                excluded_file_names=ev.excluded_file_names or None,
+                excluded_categories=ev.excluded_categories or None,
+                limit=ev.top_k,
+                offset=ev.offset,
+                min_similarity=ev.min_similarity,
             )
-            return SearchOutputEvent(results=results)
+            return SearchOutputEvent(results=results, offset=ev.offset)
 
 
 workflow = SearchWorkflow(timeout=600)
@@ -2,7 +2,14 @@ import uuid
 import os
 from pydantic import BaseModel
 from qdrant_client import AsyncQdrantClient
-from qdrant_client.models import PointStruct, Filter, FieldCondition, MatchValue
+from qdrant_client.models import (
+    PointStruct,
+    Filter,
+    FieldCondition,
+    FilterSelector,
+    MatchAny,
+    MatchValue,
+)
 from typing import cast, Literal
 from openai import AsyncOpenAI
 from .embeddings import OpenAIEmbedder
@@ -16,6 +23,21 @@ class Result(BaseModel):
     similarity: float
 
 
+def exclusion_conditions(
+    excluded_file_names: list[str] | None, excluded_categories: list[str] | None
+) -> list[FieldCondition]:
+    conditions = []
+    if excluded_file_names:
+        conditions.append(
+            FieldCondition(key="file_name", match=MatchAny(any=excluded_file_names))
+        )
+    if excluded_categories:
+        conditions.append(
+            FieldCondition(key="category", match=MatchAny(any=excluded_categories))
+        )
+    return conditions
+
+
 class SummaryVectorDB:
     def __init__(self, client: AsyncQdrantClient, collection_name: str):
         self._client = client
@@ -40,12 +62,37 @@ class SummaryVectorDB:
         self._client.upload_points(self.collection_name, points=[point])
         return None
 
+    async def delete(self, username: str, file_name: str) -> None:
+        await self._client.delete(
+            self.collection_name,
+            points_selector=FilterSelector(
+                filter=Filter(
+                    must=[
+                        FieldCondition(
+                            key="username", match=MatchValue(value=username)
+                        ),
+                        FieldCondition(
+                            key="file_name", match=MatchValue(value=file_name)
+                        ),
+                    ]
+                )
+            ),
+        )
+        return None
+
     async def search(
         self,
         text: str,
         username: str,
         category: str | None = None,
         file_name: str | None = None,
+        file_names: list[str] | None = None,
+        categories: list[str] | None = None,
+        excluded_file_names: list[str] | None = None,
+        excluded_categories: list[str] | None = None,
+        limit: int = 10,
+        offset: int = 0,
+        min_similarity: float = 0.75,
     ) -> list[Result]:
         filters = Filter(
             must=[FieldCondition(key="username", match=MatchValue(value=username))]
@@ -58,12 +105,25 @@ class SummaryVectorDB:
             (cast(list[FieldCondition], filters.must)).append(
                 FieldCondition(key="file_name", match=MatchValue(value=file_name))
             )
+        if file_names is not None:
+            (cast(list[FieldCondition], filters.must)).append(
+                FieldCondition(key="file_name", match=MatchAny(any=file_names))
+            )
+        if categories is not None:
+            (cast(list[FieldCondition], filters.must)).append(
+                FieldCondition(key="category", match=MatchAny(any=categories))
+            )
+        excluded = exclusion_conditions(excluded_file_names, excluded_categories)
+        if excluded:
+            filters.must_not = excluded
         vec = await self._embedder.embed([text])
         results = await self._client.query_points(
             self.collection_name,
             query=vec[0],
             query_filter=filters,
-            score_threshold=0.75,
+            score_threshold=min_similarity,
+            limit=limit,
+            offset=offset,
         )
         points = results.points
         return [
@@ -113,12 +173,37 @@ class FaqsVectorDB:
         self._client.upload_points(self.collection_name, points=points)
         return None
 
+    async def delete(self, username: str, file_name: str) -> None:
+        await self._client.delete(
+            self.collection_name,
+            points_selector=FilterSelector(
+                filter=Filter(
+                    must=[
+                        FieldCondition(
+                            key="username", match=MatchValue(value=username)
+                        ),
+                        FieldCondition(
+                            key="file_name", match=MatchValue(value=file_name)
+                        ),
+                    ]
+                )
+            ),
+        )
+        return None
+
     async def search(
         self,
         text: str,
         username: str,
         category: str | None = None,
         file_name: str | None = None,
+        file_names: list[str] | None = None,
+        categories: list[str] | None = None,
+        excluded_file_names: list[str] | None = None,
+        excluded_categories: list[str] | None = None,
+        limit: int = 10,
+        offset: int = 0,
+        min_similarity: float = 0.75,
     ) -> list[Result]:
         filters = Filter(
             must=[FieldCondition(key="username", match=MatchValue(value=username))]
@@ -131,12 +216,25 @@ class FaqsVectorDB:
             (cast(list[FieldCondition], filters.must)).append(
                 FieldCondition(key="file_name", match=MatchValue(value=file_name))
             )
+        if file_names is not None:
+            (cast(list[FieldCondition], filters.must)).append(
+                FieldCondition(key="file_name", match=MatchAny(any=file_names))
+            )
+        if categories is not None:
+            (cast(list[FieldCondition], filters.must)).append(
+                FieldCondition(key="category", match=MatchAny(any=categories))
+            )
+        excluded = exclusion_conditions(excluded_file_names, excluded_categories)
+        if excluded:
+            filters.must_not = excluded
         vec = await self._embedder.embed([text])
         results = await self._client.query_points(
             self.collection_name,
             query=vec[0],
             query_filter=filters,
-            score_threshold=0.75,
+            score_threshold=min_similarity,
+            limit=limit,
+            offset=offset,
         )
         points = results.points
         return [
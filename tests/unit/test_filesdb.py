@@ -14,11 +14,15 @@ async def test_file_upload() -> None:
     async with get_db_conn() as db_conn:
         querier = AsyncQuerier(conn=db_conn)
         fl = await querier.create_file(
-            username="testuser", file_name="testfile.pdf", file_category="test"
+            username="testuser",
+            file_name="testfile.pdf",
+            file_category="test",
+            llama_cloud_file_id="test-file-id",
         )
         assert fl is not None
         assert isinstance(fl, File)
         assert fl.file_category == "test"
         assert fl.username == "testuser"
         assert fl.file_name == "testfile.pdf"
+        assert fl.llama_cloud_file_id == "test-file-id"
         await db_conn.commit()